@@ -0,0 +1,73 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "local configuration",
+}
+
+// configRenderCmd prints fully resolved configuration
+var configRenderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "print fully resolved configuration (templates, generators, overlays and references applied)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		// Collect flags
+		configFile, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		if configFile == "" {
+			return fmt.Errorf("provide configuration file location via --config argument")
+		}
+
+		overlayFiles, err := cmd.Flags().GetStringArray("overlay")
+		if err != nil {
+			return err
+		}
+
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			return err
+		}
+
+		withSources, err := cmd.Flags().GetBool("sources")
+		if err != nil {
+			return err
+		}
+
+		config, err := getConfig(configFile, overlayFiles...)
+		if err != nil {
+			return err
+		}
+
+		dataBytes, err := renderConfig(config, format, withSources)
+		if err != nil {
+			return err
+		}
+		logger.Println(string(dataBytes))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+
+	configCmd.AddCommand(configRenderCmd)
+	configRenderCmd.PersistentFlags().StringP("config", "c", "", "configuration file, filepath")
+	configRenderCmd.PersistentFlags().StringArray("overlay", nil, "overlay configuration file which patches resources by ID, filepath (can be repeated)")
+	configRenderCmd.PersistentFlags().StringP(
+		"format", "f", "yaml", fmt.Sprintf("output format, one of %q", supportedRenderFormats),
+	)
+	configRenderCmd.PersistentFlags().Bool("sources", false, "include location of each resource in configuration files")
+}
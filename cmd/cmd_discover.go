@@ -0,0 +1,42 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// discoverCmd represents the discover command
+var discoverCmd = &cobra.Command{
+	Use:   "discover",
+	Short: "fetch configuration of the whole account",
+}
+
+// discoverAllCmd writes all resources of the account as configuration tree
+var discoverAllCmd = &cobra.Command{
+	Use:   "all",
+	Short: "write domains, records, Sonar checks and geoproximities as configuration tree",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		outputDir, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+		if outputDir == "" {
+			return fmt.Errorf("provide output directory via --output argument")
+		}
+
+		return discoverAccountConfig(outputDir)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(discoverCmd)
+
+	discoverCmd.AddCommand(discoverAllCmd)
+	discoverAllCmd.PersistentFlags().StringP("output", "o", "", "write configuration files to directory, path")
+}
@@ -6,9 +6,12 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"sort"
+	"time"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/cobra"
+	"golang.org/x/exp/maps"
 )
 
 // dnsCmd represents the dns command
@@ -33,7 +36,11 @@ var dnsDiscoverRecordsCmd = &cobra.Command{
 			return fmt.Errorf("requires a domain name (e.g. example.com)")
 		}
 
-		if len(args) != 1 {
+		asConfig, err := cmd.Flags().GetBool("as-config")
+		if err != nil {
+			return err
+		}
+		if len(args) != 1 && !asConfig {
 			return fmt.Errorf("cannot discover multiple domain names at once")
 		}
 		return nil
@@ -46,11 +53,23 @@ var dnsDiscoverRecordsCmd = &cobra.Command{
 			return err
 		}
 
+		asConfig, err := cmd.Flags().GetBool("as-config")
+		if err != nil {
+			return err
+		}
+
 		domains, err := GetDNSDomains()
 		if err != nil {
 			return err
 		}
 
+		if asConfig {
+			if outputFile == "" {
+				return fmt.Errorf("provide output directory via --output argument")
+			}
+			return discoverDNSRecordsAsConfig(domains, args, outputFile)
+		}
+
 		var domainID int
 
 		for _, domain := range domains {
@@ -75,6 +94,41 @@ var dnsDiscoverRecordsCmd = &cobra.Command{
 	},
 }
 
+// discoverDNSRecordsAsConfig writes records of the domains as configuration
+// tree and verifies that it matches active records
+func discoverDNSRecordsAsConfig(domains []*DNSDomain, domainNames []string, outputDir string) error {
+	layout := newConfigLayout()
+	refs := &discoveryReferences{}
+	active := make(map[string][]*DNSRecord)
+	for _, domainName := range domainNames {
+		var domainID int
+		for _, domain := range domains {
+			if domain.Name == domainName {
+				domainID = domain.ID
+			}
+		}
+		if domainID == 0 {
+			return fmt.Errorf("domain %s not found", domainName)
+		}
+		records, err := GetDNSRecords(domainID)
+		if err != nil {
+			return err
+		}
+		logger.Printf("Found %d DNS records for %s\n", len(records), domainName)
+		discovered, err := toDiscoveredDNSRecords(records, refs)
+		if err != nil {
+			return err
+		}
+		layout.addDNSRecords(domainName, discovered)
+		active[domainName] = records
+	}
+	mainFile, err := layout.write(outputDir)
+	if err != nil {
+		return err
+	}
+	return verifyDiscoveredConfig(mainFile, &discoveredResources{DNS: active})
+}
+
 // dnsDiscoverDomainsCmd fetch existing domains from Constellix
 var dnsDiscoverDomainsCmd = &cobra.Command{
 	Use:   "domains",
@@ -144,6 +198,11 @@ var dnsSyncCmd = &cobra.Command{
 			return err
 		}
 
+		removeUnmanaged, err := cmd.Flags().GetBool("remove-unmanaged")
+		if err != nil {
+			return err
+		}
+
 		only, err := cmd.Flags().GetString("only")
 		if err != nil {
 			return err
@@ -152,12 +211,35 @@ var dnsSyncCmd = &cobra.Command{
 			logger.Printf("syncing only %s domain", only)
 		}
 
-		config, err := getConfig(configFile)
+		overlayFiles, err := cmd.Flags().GetStringArray("overlay")
+		if err != nil {
+			return err
+		}
+
+		waitPropagation, err := cmd.Flags().GetBool("wait-propagation")
+		if err != nil {
+			return err
+		}
+		if waitPropagation && !doit {
+			return fmt.Errorf("--wait-propagation requires --doit flag")
+		}
+
+		propagationTimeout, err := cmd.Flags().GetDuration("propagation-timeout")
+		if err != nil {
+			return err
+		}
+
+		resolver, err := cmd.Flags().GetString("resolver")
+		if err != nil {
+			return err
+		}
+
+		config, err := getConfig(configFile, overlayFiles...)
 		if err != nil {
 			return err
 		}
 
-		if len(config.DNS) == 0 {
+		if len(config.DNS) == 0 && len(config.Domains) == 0 {
 			logger.Println("No DNS configuration found")
 			return nil
 		}
@@ -167,32 +249,85 @@ var dnsSyncCmd = &cobra.Command{
 			return err
 		}
 
+		// Domains are synced first, so records of new domains can be created
+		var expectedDomains []*ExpectedDNSDomain
+		for _, domain := range config.Domains {
+			if only == "" || only == domain.Name {
+				expectedDomains = append(expectedDomains, domain)
+			}
+		}
+		if len(expectedDomains) > 0 {
+			activeDomains := filterConfiguredDNSDomains(domains, expectedDomains)
+			err = Sync(toResourceMatcher(expectedDomains), toResourceMatcher(activeDomains), doit, allowRemoving, "DNS domains")
+			if err != nil {
+				return err
+			}
+			if doit {
+				domains, err = GetDNSDomains()
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		var propagationTargets []*dnsPropagationTarget
 		for domainName := range config.DNS {
 			if only != "" && only != domainName {
 				continue
 			}
 			var domainID int
+			var nameservers []string
 
 			for _, domain := range domains {
 				if domain.Name == domainName {
 					domainID = domain.ID
+					nameservers = domain.Nameservers
+					warnGTDDisabled(domain, config.DNS[domainName])
 				}
 			}
+			if resolver != "" {
+				nameservers = []string{resolver}
+			}
 
+			var records []*DNSRecord
 			if domainID == 0 {
-				return fmt.Errorf("domain %s not found", domainName)
+				isPlanned := false
+				for _, domain := range expectedDomains {
+					if domain.Name == domainName {
+						isPlanned = true
+					}
+				}
+				if doit || !isPlanned {
+					return fmt.Errorf("domain %s not found", domainName)
+				}
+				// The domain will be created, all records are new
+				logger.Printf("domain %s will be created", domainName)
 			} else {
 				if rootVerbose {
 					logger.Printf("domain %s found with ID %d", domainName, domainID)
 				}
-			}
-			records, err := GetDNSRecords(domainID)
-			if err != nil {
-				return err
+				records, err = GetDNSRecords(domainID)
+				if err != nil {
+					return err
+				}
 			}
 			for _, item := range config.DNS[domainName] {
 				item.domainIDInConstellix = domainID
 			}
+			for _, item := range records {
+				item.removable = removeUnmanaged
+			}
+			records = filterProtectedDNSRecords(records, config.DNS[domainName])
+			if waitPropagation {
+				if len(nameservers) == 0 {
+					return fmt.Errorf("domain %s has no nameservers, provide DNS server via --resolver argument", domainName)
+				}
+				targets, err := dnsPropagationTargets(domainName, nameservers, config.DNS[domainName], records, allowRemoving)
+				if err != nil {
+					return err
+				}
+				propagationTargets = append(propagationTargets, targets...)
+			}
 			activeRecords := toResourceMatcher(records)
 			expectedRecords := toResourceMatcher(config.DNS[domainName])
 			err = Sync(expectedRecords, activeRecords, doit, allowRemoving, "DNS records for "+domainName)
@@ -200,6 +335,12 @@ var dnsSyncCmd = &cobra.Command{
 				return err
 			}
 		}
+		if waitPropagation {
+			err = waitDNSPropagation(propagationTargets, propagationTimeout)
+			if err != nil {
+				return err
+			}
+		}
 		var message string
 		if !doit {
 			message += "apply changes by passing --doit flag"
@@ -218,17 +359,73 @@ var dnsSyncCmd = &cobra.Command{
 	},
 }
 
+// dnsRegionsCmd reports GTD regions covered by the records from configuration
+var dnsRegionsCmd = &cobra.Command{
+	Use:   "regions",
+	Short: "report GTD regions covered by records for each name",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		// Collect flags
+		configFile, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		if configFile == "" {
+			return fmt.Errorf("provide configuration file location via --config argument")
+		}
+
+		only, err := cmd.Flags().GetString("only")
+		if err != nil {
+			return err
+		}
+
+		overlayFiles, err := cmd.Flags().GetStringArray("overlay")
+		if err != nil {
+			return err
+		}
+
+		config, err := getConfig(configFile, overlayFiles...)
+		if err != nil {
+			return err
+		}
+
+		domainNames := maps.Keys(config.DNS)
+		sort.Strings(domainNames)
+		for _, domainName := range domainNames {
+			if only != "" && only != domainName {
+				continue
+			}
+			reportGTDRegions(domainName, config.DNS[domainName])
+		}
+		return nil
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(dnsCmd)
 	dnsCmd.AddCommand(dnsDiscoverCmd)
 	dnsDiscoverCmd.AddCommand(dnsDiscoverRecordsCmd)
+	dnsDiscoverRecordsCmd.Flags().Bool(
+		"as-config", false, "write configuration tree (main config and a file per domain) to the output directory",
+	)
 	dnsDiscoverCmd.PersistentFlags().StringP("output", "o", "", "write output in yaml format to file, filepath")
 
 	dnsDiscoverCmd.AddCommand(dnsDiscoverDomainsCmd)
 
 	dnsCmd.AddCommand(dnsSyncCmd)
 	dnsSyncCmd.PersistentFlags().StringP("config", "c", "", "configuration file, filepath")
+	dnsSyncCmd.PersistentFlags().StringArray("overlay", nil, "overlay configuration file which patches resources by ID, filepath (can be repeated)")
 	dnsSyncCmd.PersistentFlags().Bool("doit", false, "apply planned changes")
 	dnsSyncCmd.PersistentFlags().Bool("remove", false, "remove resources which are not present in configuration file")
+	dnsSyncCmd.PersistentFlags().Bool("remove-unmanaged", false, "remove records of unsupported types, requires --remove flag")
 	dnsSyncCmd.PersistentFlags().String("only", "", "execute sync command only for specified domain name")
+	dnsSyncCmd.PersistentFlags().Bool("wait-propagation", false, "wait until nameservers answer with changed records, requires --doit flag")
+	dnsSyncCmd.PersistentFlags().Duration("propagation-timeout", 10*time.Minute, "maximum time to wait for propagation")
+	dnsSyncCmd.PersistentFlags().String("resolver", "", "query this DNS server instead of nameservers of domains while waiting for propagation, host[:port]")
+
+	dnsCmd.AddCommand(dnsRegionsCmd)
+	dnsRegionsCmd.PersistentFlags().StringP("config", "c", "", "configuration file, filepath")
+	dnsRegionsCmd.PersistentFlags().StringArray("overlay", nil, "overlay configuration file which patches resources by ID, filepath (can be repeated)")
+	dnsRegionsCmd.PersistentFlags().String("only", "", "report only for specified domain name")
 }
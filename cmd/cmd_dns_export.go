@@ -0,0 +1,83 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// dnsExportCmd represents the export DNS command
+var dnsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "convert DNS records from Constellix into other formats",
+}
+
+// dnsExportZonefileCmd renders records of the domain as RFC 1035 zone file
+var dnsExportZonefileCmd = &cobra.Command{
+	Use:   "zonefile <domain name>",
+	Short: "render DNS records of the domain as BIND zone file",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		outputFile, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+
+		domains, err := GetDNSDomains()
+		if err != nil {
+			return err
+		}
+		var domain *DNSDomain
+		for _, item := range domains {
+			if item.Name == args[0] {
+				domain = item
+			}
+		}
+		if domain == nil {
+			return fmt.Errorf("domain %s not found", args[0])
+		}
+
+		records, err := GetDNSRecords(domain.ID)
+		if err != nil {
+			return err
+		}
+		var pools []*Pool
+		for _, record := range records {
+			if record.Mode == "pools" {
+				pools, err = GetPools()
+				if err != nil {
+					return err
+				}
+				break
+			}
+		}
+
+		if outputFile == "" {
+			return exportZoneFile(os.Stdout, domain, records, pools)
+		}
+		f, err := os.Create(outputFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		err = exportZoneFile(f, domain, records, pools)
+		if err != nil {
+			return err
+		}
+		logger.Printf("Zone file saved to %s\n", outputFile)
+		return nil
+	},
+}
+
+func init() {
+	dnsCmd.AddCommand(dnsExportCmd)
+	dnsExportCmd.PersistentFlags().StringP("output", "o", "", "write output to file, filepath")
+
+	dnsExportCmd.AddCommand(dnsExportZonefileCmd)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// dnsImportCmd represents the import DNS command
+var dnsImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "convert DNS records from other formats into configuration",
+}
+
+// dnsImportZonefileCmd converts RFC 1035 zone file into DNS records configuration
+var dnsImportZonefileCmd = &cobra.Command{
+	Use:   "zonefile <file>",
+	Short: "convert BIND zone file into DNS records configuration",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		domainName, err := cmd.Flags().GetString("domain")
+		if err != nil {
+			return err
+		}
+		if domainName == "" {
+			return fmt.Errorf("provide domain name via --domain argument")
+		}
+
+		outputFile, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		zoneRecords, err := parseZoneFile(f, domainName)
+		if err != nil {
+			return fmt.Errorf("unable to parse zone file %s: %s", args[0], err)
+		}
+		records, problems := importZoneRecords(zoneRecords, domainName)
+		logger.Printf("Converted %d resource records into %d DNS records\n", len(zoneRecords), len(records))
+		reportImportProblems(problems)
+		return writeDiscoveryResult(records, outputFile)
+	},
+}
+
+// dnsImportOctodnsCmd converts octoDNS zone file into DNS records configuration
+var dnsImportOctodnsCmd = &cobra.Command{
+	Use:   "octodns <zone.yaml>",
+	Short: "convert octoDNS zone file into DNS records configuration",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		outputFile, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		records, problems, err := importOctodnsRecords(data, args[0])
+		if err != nil {
+			return fmt.Errorf("unable to parse octoDNS zone file %s: %s", args[0], err)
+		}
+		logger.Printf("Converted %d DNS records\n", len(records))
+		reportImportProblems(problems)
+		return writeDiscoveryResult(records, outputFile)
+	},
+}
+
+func init() {
+	dnsCmd.AddCommand(dnsImportCmd)
+	dnsImportCmd.PersistentFlags().StringP("output", "o", "", "write output in yaml format to file, filepath")
+
+	dnsImportCmd.AddCommand(dnsImportZonefileCmd)
+	dnsImportZonefileCmd.Flags().String("domain", "", "domain name of the zone, used as the initial origin")
+
+	dnsImportCmd.AddCommand(dnsImportOctodnsCmd)
+}
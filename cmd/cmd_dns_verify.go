@@ -0,0 +1,102 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// dnsVerifyCmd queries nameservers of the domain for records from configuration
+var dnsVerifyCmd = &cobra.Command{
+	Use:   "verify <domain name>",
+	Short: "query nameservers of the domain and compare answers with standard-mode records from configuration",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		domainName := args[0]
+
+		// Collect flags
+		configFile, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		if configFile == "" {
+			return fmt.Errorf("provide configuration file location via --config argument")
+		}
+
+		overlayFiles, err := cmd.Flags().GetStringArray("overlay")
+		if err != nil {
+			return err
+		}
+
+		resolver, err := cmd.Flags().GetString("resolver")
+		if err != nil {
+			return err
+		}
+
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		if err != nil {
+			return err
+		}
+
+		config, err := getConfig(configFile, overlayFiles...)
+		if err != nil {
+			return err
+		}
+		records, ok := config.DNS[domainName]
+		if !ok {
+			return fmt.Errorf("no records of domain %s in configuration", domainName)
+		}
+
+		nameservers, err := getVerifyNameservers(domainName, resolver)
+		if err != nil {
+			return err
+		}
+
+		results := verifyDNSRecords(domainName, records, nameservers, timeout)
+		reportDNSVerifications(results)
+		failed := 0
+		for _, result := range results {
+			if result.Status == VerifyMismatch || result.Status == VerifyError {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d answers don't match configuration", failed)
+		}
+		return nil
+	},
+}
+
+// getVerifyNameservers returns nameservers of the domain from Constellix, the
+// resolver replaces them when it's defined
+func getVerifyNameservers(domainName, resolver string) ([]string, error) {
+	if resolver != "" {
+		return []string{resolver}, nil
+	}
+	domains, err := GetDNSDomains()
+	if err != nil {
+		return nil, err
+	}
+	for _, domain := range domains {
+		if domain.Name == domainName {
+			if len(domain.Nameservers) == 0 {
+				return nil, fmt.Errorf("domain %s has no nameservers", domainName)
+			}
+			return domain.Nameservers, nil
+		}
+	}
+	return nil, fmt.Errorf("domain %s not found", domainName)
+}
+
+func init() {
+	dnsCmd.AddCommand(dnsVerifyCmd)
+	dnsVerifyCmd.PersistentFlags().StringP("config", "c", "", "configuration file, filepath")
+	dnsVerifyCmd.PersistentFlags().StringArray("overlay", nil, "overlay configuration file which patches resources by ID, filepath (can be repeated)")
+	dnsVerifyCmd.PersistentFlags().String("resolver", "", "query this DNS server instead of nameservers of the domain, host[:port]")
+	dnsVerifyCmd.PersistentFlags().Duration("timeout", 5*time.Second, "timeout of a single DNS query")
+}
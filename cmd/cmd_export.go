@@ -0,0 +1,139 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "convert configuration into other formats",
+}
+
+// exportTerraformCmd renders the resolved configuration as Terraform resources
+var exportTerraformCmd = &cobra.Command{
+	Use:   "terraform",
+	Short: "render resolved configuration as resources of Constellix Terraform provider",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		// Collect flags
+		configFile, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		if configFile == "" {
+			return fmt.Errorf("provide configuration file location via --config argument")
+		}
+
+		overlayFiles, err := cmd.Flags().GetStringArray("overlay")
+		if err != nil {
+			return err
+		}
+
+		outputFile, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+
+		config, err := getConfig(configFile, overlayFiles...)
+		if err != nil {
+			return err
+		}
+
+		lookup, err := getTerraformLookup(config)
+		if err != nil {
+			return err
+		}
+
+		if outputFile == "" {
+			return exportTerraform(os.Stdout, config, lookup)
+		}
+		f, err := os.Create(outputFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		err = exportTerraform(f, config, lookup)
+		if err != nil {
+			return err
+		}
+		logger.Printf("Terraform configuration saved to %s\n", outputFile)
+		return nil
+	},
+}
+
+// getTerraformLookup retrieves names of resources which are referenced by
+// records. References in the configuration are already resolved to IDs
+func getTerraformLookup(config *Config) (*terraformLookup, error) {
+	lookup := &terraformLookup{
+		HTTPChecks:     make(map[int]string),
+		TCPChecks:      make(map[int]string),
+		GeoProximities: make(map[int]string),
+		IPFilters:      make(map[int]string),
+	}
+	// Both types of checks are retrieved to detect IDs which are used by an
+	// HTTP and a TCP check
+	hasChecks := len(config.SonarHTTPChecks) > 0 || len(config.SonarTCPChecks) > 0
+	if hasChecks {
+		checks, err := GetSonarHTTPChecks()
+		if err != nil {
+			return nil, err
+		}
+		for _, check := range checks {
+			lookup.HTTPChecks[check.ID] = check.Name
+		}
+	}
+	if hasChecks {
+		checks, err := GetSonarTCPChecks()
+		if err != nil {
+			return nil, err
+		}
+		for _, check := range checks {
+			lookup.TCPChecks[check.ID] = check.Name
+		}
+	}
+	if len(config.GeoProximities) > 0 {
+		proximities, err := GetGeoProximities()
+		if err != nil {
+			return nil, err
+		}
+		for _, gp := range proximities {
+			lookup.GeoProximities[gp.ID] = gp.Name
+		}
+	}
+	// IP filters are not exported, their names are used in comments
+	hasIPFilters := false
+	for _, records := range config.DNS {
+		for _, record := range records {
+			if record.IPFilter != nil {
+				hasIPFilters = true
+			}
+		}
+	}
+	if hasIPFilters {
+		ipFilters, err := GetIPFilters()
+		if err != nil {
+			return nil, err
+		}
+		for _, ipFilter := range ipFilters {
+			lookup.IPFilters[ipFilter.ID] = ipFilter.Name
+		}
+	}
+	return lookup, nil
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.AddCommand(exportTerraformCmd)
+	exportTerraformCmd.PersistentFlags().StringP("config", "c", "", "configuration file, filepath")
+	exportTerraformCmd.PersistentFlags().StringArray("overlay", nil, "overlay configuration file which patches resources by ID, filepath (can be repeated)")
+	exportTerraformCmd.PersistentFlags().StringP("output", "o", "", "write output to file, filepath")
+}
@@ -63,7 +63,12 @@ var geoproximitySyncCmd = &cobra.Command{
 			return err
 		}
 
-		config, err := getConfig(configFile)
+		overlayFiles, err := cmd.Flags().GetStringArray("overlay")
+		if err != nil {
+			return err
+		}
+
+		config, err := getConfig(configFile, overlayFiles...)
 		if err != nil {
 			return err
 		}
@@ -105,6 +110,7 @@ func init() {
 
 	geoproximityCmd.AddCommand(geoproximitySyncCmd)
 	geoproximitySyncCmd.PersistentFlags().StringP("config", "c", "", "configuration file, filepath")
+	geoproximitySyncCmd.PersistentFlags().StringArray("overlay", nil, "overlay configuration file which patches resources by ID, filepath (can be repeated)")
 	geoproximitySyncCmd.PersistentFlags().Bool("doit", false, "apply planned changes")
 	geoproximitySyncCmd.PersistentFlags().Bool("remove", false, "remove resources which are not present in configuration file")
 }
@@ -0,0 +1,115 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// ipfilterCmd represents the ipfilter command
+var ipfilterCmd = &cobra.Command{
+	Use:   "ipfilter",
+	Short: "IP filters configuration",
+}
+
+// ipfilterDiscoverCmd fetch existing IP filters from Constellix
+var ipfilterDiscoverCmd = &cobra.Command{
+	Use:   "discover",
+	Short: "fetch IP filters configuration",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		outputFile, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+
+		ipFilters, err := GetIPFilters()
+		if err != nil {
+			return err
+		}
+		logger.Printf("Found %d IP filters\n", len(ipFilters))
+
+		return writeDiscoveryResult(ipFilters, outputFile)
+	},
+}
+
+// ipfilterSyncCmd represents the sync ipfilter command
+var ipfilterSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "sync configuration to Constellix",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		// Collect flags
+		configFile, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		if configFile == "" {
+			return fmt.Errorf("provide configuration file location via --config argument")
+		}
+
+		doit, err := cmd.Flags().GetBool("doit")
+		if err != nil {
+			return err
+		}
+
+		allowRemoving, err := cmd.Flags().GetBool("remove")
+		if err != nil {
+			return err
+		}
+
+		overlayFiles, err := cmd.Flags().GetStringArray("overlay")
+		if err != nil {
+			return err
+		}
+
+		config, err := getConfig(configFile, overlayFiles...)
+		if err != nil {
+			return err
+		}
+
+		ipFilters, err := GetIPFilters()
+		if err != nil {
+			return err
+		}
+		activeIPFilters := toResourceMatcher(ipFilters)
+		expectedIPFilters := toResourceMatcher(config.IPFilters)
+		err = Sync(expectedIPFilters, activeIPFilters, doit, allowRemoving, "IP filters")
+		if err != nil {
+			return err
+		}
+		var message string
+		if !doit {
+			message += "apply changes by passing --doit flag"
+		}
+		if !allowRemoving {
+			if message != "" {
+				message += "; "
+			}
+			message += "allow removing of resources by passing --remove flag"
+		}
+		if message == "" {
+			message = "done"
+		}
+		logger.Println(message)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(ipfilterCmd)
+
+	ipfilterCmd.AddCommand(ipfilterDiscoverCmd)
+	ipfilterDiscoverCmd.PersistentFlags().StringP("output", "o", "", "write output in yaml format to file, filepath")
+
+	ipfilterCmd.AddCommand(ipfilterSyncCmd)
+	ipfilterSyncCmd.PersistentFlags().StringP("config", "c", "", "configuration file, filepath")
+	ipfilterSyncCmd.PersistentFlags().StringArray("overlay", nil, "overlay configuration file which patches resources by ID, filepath (can be repeated)")
+	ipfilterSyncCmd.PersistentFlags().Bool("doit", false, "apply planned changes")
+	ipfilterSyncCmd.PersistentFlags().Bool("remove", false, "remove resources which are not present in configuration file")
+}
@@ -0,0 +1,115 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// poolCmd represents the pool command
+var poolCmd = &cobra.Command{
+	Use:   "pool",
+	Short: "pools configuration (A, AAAA and CNAME)",
+}
+
+// poolDiscoverCmd fetch existing pools from Constellix
+var poolDiscoverCmd = &cobra.Command{
+	Use:   "discover",
+	Short: "fetch pools configuration",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		outputFile, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return err
+		}
+
+		pools, err := GetPools()
+		if err != nil {
+			return err
+		}
+		logger.Printf("Found %d pools\n", len(pools))
+
+		return writeDiscoveryResult(pools, outputFile)
+	},
+}
+
+// poolSyncCmd represents the sync pool command
+var poolSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "sync configuration to Constellix",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		// Collect flags
+		configFile, err := cmd.Flags().GetString("config")
+		if err != nil {
+			return err
+		}
+		if configFile == "" {
+			return fmt.Errorf("provide configuration file location via --config argument")
+		}
+
+		doit, err := cmd.Flags().GetBool("doit")
+		if err != nil {
+			return err
+		}
+
+		allowRemoving, err := cmd.Flags().GetBool("remove")
+		if err != nil {
+			return err
+		}
+
+		overlayFiles, err := cmd.Flags().GetStringArray("overlay")
+		if err != nil {
+			return err
+		}
+
+		config, err := getConfig(configFile, overlayFiles...)
+		if err != nil {
+			return err
+		}
+
+		pools, err := GetPools()
+		if err != nil {
+			return err
+		}
+		activePools := toResourceMatcher(pools)
+		expectedPools := toResourceMatcher(config.Pools)
+		err = Sync(expectedPools, activePools, doit, allowRemoving, "Pools")
+		if err != nil {
+			return err
+		}
+		var message string
+		if !doit {
+			message += "apply changes by passing --doit flag"
+		}
+		if !allowRemoving {
+			if message != "" {
+				message += "; "
+			}
+			message += "allow removing of resources by passing --remove flag"
+		}
+		if message == "" {
+			message = "done"
+		}
+		logger.Println(message)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(poolCmd)
+
+	poolCmd.AddCommand(poolDiscoverCmd)
+	poolDiscoverCmd.PersistentFlags().StringP("output", "o", "", "write output in yaml format to file, filepath")
+
+	poolCmd.AddCommand(poolSyncCmd)
+	poolSyncCmd.PersistentFlags().StringP("config", "c", "", "configuration file, filepath")
+	poolSyncCmd.PersistentFlags().StringArray("overlay", nil, "overlay configuration file which patches resources by ID, filepath (can be repeated)")
+	poolSyncCmd.PersistentFlags().Bool("doit", false, "apply planned changes")
+	poolSyncCmd.PersistentFlags().Bool("remove", false, "remove resources which are not present in configuration file")
+}
@@ -222,7 +222,12 @@ var sonarSyncCmd = &cobra.Command{
 			return err
 		}
 
-		config, err := getConfig(configFile)
+		overlayFiles, err := cmd.Flags().GetStringArray("overlay")
+		if err != nil {
+			return err
+		}
+
+		config, err := getConfig(configFile, overlayFiles...)
 		if err != nil {
 			return err
 		}
@@ -285,6 +290,7 @@ func init() {
 
 	sonarCmd.AddCommand(sonarSyncCmd)
 	sonarSyncCmd.PersistentFlags().StringP("config", "c", "", "configuration file, filepath")
+	sonarSyncCmd.PersistentFlags().StringArray("overlay", nil, "overlay configuration file which patches resources by ID, filepath (can be repeated)")
 	sonarSyncCmd.PersistentFlags().Bool("doit", false, "apply planned changes")
 	sonarSyncCmd.PersistentFlags().Bool("remove", false, "remove resources which are not present in configuration file")
 }
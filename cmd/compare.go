@@ -28,6 +28,14 @@ type IExpectedResource interface {
 	SyncResourceUpdate(int) error
 }
 
+// IConfiguredResource implements resource which is defined in configuration
+// files
+type IConfiguredResource interface {
+	// Returns location of the resource in configuration files
+	GetSource() string
+	SetSource(string)
+}
+
 // IActiveResource implements remote / active resource
 type IActiveResource interface {
 	// Returns ID of the resource in Constellix
@@ -40,6 +48,14 @@ type IActiveResource interface {
 	SyncResourceDelete(int) error
 }
 
+// IUnmanagedResource implements active resource which can't be managed via
+// configuration, e.g. a DNS record of unsupported type. Unmanaged resources are
+// reported, but never deleted unless it is explicitly allowed
+type IUnmanagedResource interface {
+	IsUnmanaged() bool
+	IsRemovable() bool
+}
+
 // ResourceMatcher implements resources to compare
 type ResourceMatcher interface {
 	GetResourceID() string
@@ -1,132 +1,301 @@
 package cmd
 
 import (
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 	"gopkg.in/yaml.v3"
 )
 
 type MainConfig struct {
+	// Other main configuration files to merge with this one
+	Include    []string `yaml:"include,omitempty"`
 	Constellix struct {
-		Sonar                   SonarConfig         `yaml:"sonar"`
-		GeoProximityConfigFiles []string            `yaml:"geoproximity"`
-		DNS                     map[string][]string `yaml:"dns"`
+		Sonar                   SonarConfig          `yaml:"sonar,omitempty"`
+		GeoProximityConfigFiles []string             `yaml:"geoproximity,omitempty"`
+		PoolConfigFiles         []string             `yaml:"pools,omitempty"`
+		IPFilterConfigFiles     []string             `yaml:"ipfilters,omitempty"`
+		DomainConfigFiles       []string             `yaml:"domains,omitempty"`
+		DNS                     map[string][]string  `yaml:"dns,omitempty"`
+		Templates               map[string]yaml.Node `yaml:"templates,omitempty"`
 	} `yaml:"constellix"`
 }
 
 type SonarConfig struct {
-	HTTPChecksConfigFiles []string `yaml:"http_checks"`
-	TCPChecksConfigFiles  []string `yaml:"tcp_checks"`
+	HTTPChecksConfigFiles []string `yaml:"http_checks,omitempty"`
+	TCPChecksConfigFiles  []string `yaml:"tcp_checks,omitempty"`
 }
 
 type Config struct {
 	SonarHTTPChecks []*ExpectedSonarHTTPCheck
 	SonarTCPChecks  []*ExpectedSonarTCPCheck
+	Domains         []*ExpectedDNSDomain
 	DNS             map[string][]*ExpectedDNSRecord
 	GeoProximities  []*ExpectedGeoProximity
+	Pools           []*ExpectedPool
+	IPFilters       []*ExpectedIPFilter
 }
 
-func getConfig(configFile string) (*Config, error) {
-	// Read configuration file
-	if logLevel > 0 {
-		logger.Printf("Reading configuration file %s...\n", configFile)
-	}
-	dataBytes, err := os.ReadFile(configFile)
+func getConfig(configFile string, overlayFiles ...string) (*Config, error) {
+	mainConfig, err := readMainConfig(configFile)
 	if err != nil {
 		return nil, err
 	}
+	baseDir := filepath.Dir(configFile)
+	templates := mainConfig.Constellix.Templates
 
-	var mainConfig MainConfig
-	err = yaml.Unmarshal(dataBytes, &mainConfig)
+	overlays, err := readOverlays(overlayFiles)
 	if err != nil {
 		return nil, err
 	}
 
 	var config Config
-	dataB, err := readConfigs(mainConfig.Constellix.Sonar.HTTPChecksConfigFiles, filepath.Dir(configFile))
+	nodes, err := readResourceNodes(mainConfig.Constellix.Sonar.HTTPChecksConfigFiles, baseDir)
 	if err != nil {
 		return nil, err
 	}
-	for _, item := range dataB {
-		var httpChecks []*ExpectedSonarHTTPCheck
-		err = yaml.Unmarshal(item, &httpChecks)
+	for _, overlay := range overlays {
+		nodes, err = applyOverlay(nodes, overlay.Constellix.Sonar.HTTPChecks, overlay.fileName, templates, sonarHTTPCheckNodeID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", overlay.fileName, err)
+		}
+	}
+	err = decodeResourceNodes(nodes, templates, &config.SonarHTTPChecks)
+	if err != nil {
+		return nil, err
+	}
+	for _, check := range config.SonarHTTPChecks {
+		err = check.Validate()
 		if err != nil {
 			return nil, err
 		}
-		if len(httpChecks) > 0 {
-			config.SonarHTTPChecks = append(config.SonarHTTPChecks, httpChecks...)
-			for _, check := range httpChecks {
-				err = check.Validate()
-				if err != nil {
-					return nil, err
-				}
-			}
+	}
+
+	nodes, err = readResourceNodes(mainConfig.Constellix.Sonar.TCPChecksConfigFiles, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, overlay := range overlays {
+		nodes, err = applyOverlay(nodes, overlay.Constellix.Sonar.TCPChecks, overlay.fileName, templates, sonarTCPCheckNodeID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", overlay.fileName, err)
+		}
+	}
+	err = decodeResourceNodes(nodes, templates, &config.SonarTCPChecks)
+	if err != nil {
+		return nil, err
+	}
+	for _, check := range config.SonarTCPChecks {
+		err = check.Validate()
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	dataB, err = readConfigs(mainConfig.Constellix.Sonar.TCPChecksConfigFiles, filepath.Dir(configFile))
+	// Domains
+	nodes, err = readResourceNodes(mainConfig.Constellix.DomainConfigFiles, baseDir)
 	if err != nil {
 		return nil, err
 	}
-	for _, item := range dataB {
-		var tcpChecks []*ExpectedSonarTCPCheck
-		err = yaml.Unmarshal(item, &tcpChecks)
+	for _, overlay := range overlays {
+		nodes, err = applyOverlay(nodes, overlay.Constellix.Domains, overlay.fileName, templates, dnsDomainNodeID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", overlay.fileName, err)
+		}
+	}
+	err = decodeResourceNodes(nodes, templates, &config.Domains)
+	if err != nil {
+		return nil, err
+	}
+	// Settings of a domain can be defined only once, e.g. in one of the fragments
+	domainSources := make(map[string]string)
+	for _, domain := range config.Domains {
+		err = domain.Validate()
 		if err != nil {
 			return nil, err
 		}
-		if len(tcpChecks) > 0 {
-			config.SonarTCPChecks = append(config.SonarTCPChecks, tcpChecks...)
-			for _, check := range tcpChecks {
-				err = check.Validate()
-				if err != nil {
-					return nil, err
-				}
-			}
+		if source, ok := domainSources[domain.Name]; ok {
+			return nil, fmt.Errorf("settings of domain %s are defined in both %s and %s", domain.Name, source, domain.GetSource())
 		}
+		domainSources[domain.Name] = domain.GetSource()
 	}
 
 	// DNS
 	config.DNS = make(map[string][]*ExpectedDNSRecord)
-	for domainName, cfs := range mainConfig.Constellix.DNS {
-		dataB, err = readConfigs(cfs, filepath.Dir(configFile))
+	domainNames := maps.Keys(mainConfig.Constellix.DNS)
+	for _, overlay := range overlays {
+		for domainName := range overlay.Constellix.DNS {
+			if !slices.Contains(domainNames, domainName) {
+				domainNames = append(domainNames, domainName)
+			}
+		}
+	}
+	for _, domainName := range domainNames {
+		nodes, err = readResourceNodes(mainConfig.Constellix.DNS[domainName], baseDir)
+		if err != nil {
+			return nil, err
+		}
+		nodes, err = expandGenerators(nodes)
 		if err != nil {
 			return nil, err
 		}
-		for _, dataItem := range dataB {
-			var records []*ExpectedDNSRecord
-			err = yaml.Unmarshal(dataItem, &records)
+		for _, overlay := range overlays {
+			nodes, err = applyOverlay(nodes, overlay.Constellix.DNS[domainName], overlay.fileName, templates, dnsRecordNodeID)
 			if err != nil {
-				return nil, err
+				return nil, fmt.Errorf("%s: %s: %s", overlay.fileName, domainName, err)
 			}
-			config.DNS[domainName] = append(config.DNS[domainName], records...)
 		}
+		if len(nodes) == 0 {
+			continue
+		}
+		var records []*ExpectedDNSRecord
+		err = decodeResourceNodes(nodes, templates, &records)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			err = record.Validate()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", record.GetSource(), err)
+			}
+		}
+		config.DNS[domainName] = records
 	}
 
 	// GeoProximities
-	dataB, err = readConfigs(mainConfig.Constellix.GeoProximityConfigFiles, filepath.Dir(configFile))
+	nodes, err = readResourceNodes(mainConfig.Constellix.GeoProximityConfigFiles, baseDir)
 	if err != nil {
 		return nil, err
 	}
-	for _, item := range dataB {
-		var geops []*ExpectedGeoProximity
-		err = yaml.Unmarshal(item, &geops)
+	for _, overlay := range overlays {
+		nodes, err = applyOverlay(nodes, overlay.Constellix.GeoProximities, overlay.fileName, templates, geoProximityNodeID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", overlay.fileName, err)
+		}
+	}
+	err = decodeResourceNodes(nodes, templates, &config.GeoProximities)
+	if err != nil {
+		return nil, err
+	}
+	for _, check := range config.GeoProximities {
+		err = check.Validate()
 		if err != nil {
 			return nil, err
 		}
-		if len(geops) > 0 {
-			config.GeoProximities = append(config.GeoProximities, geops...)
-			for _, check := range geops {
-				err = check.Validate()
-				if err != nil {
-					return nil, err
-				}
-			}
+	}
+
+	// Pools
+	nodes, err = readResourceNodes(mainConfig.Constellix.PoolConfigFiles, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, overlay := range overlays {
+		nodes, err = applyOverlay(nodes, overlay.Constellix.Pools, overlay.fileName, templates, poolNodeID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", overlay.fileName, err)
+		}
+	}
+	err = decodeResourceNodes(nodes, templates, &config.Pools)
+	if err != nil {
+		return nil, err
+	}
+	for _, pool := range config.Pools {
+		err = pool.Validate()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// IP filters
+	nodes, err = readResourceNodes(mainConfig.Constellix.IPFilterConfigFiles, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, overlay := range overlays {
+		nodes, err = applyOverlay(nodes, overlay.Constellix.IPFilters, overlay.fileName, templates, ipFilterNodeID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", overlay.fileName, err)
+		}
+	}
+	err = decodeResourceNodes(nodes, templates, &config.IPFilters)
+	if err != nil {
+		return nil, err
+	}
+	for _, ipFilter := range config.IPFilters {
+		err = ipFilter.Validate()
+		if err != nil {
+			return nil, err
 		}
 	}
 	return &config, nil
 }
 
+// resourceNode is a resource definition from configuration files which is not
+// decoded yet, so templates, generators and overlays can be applied to it
+type resourceNode struct {
+	*yaml.Node
+	// Location of the definition, file:line
+	source string
+}
+
+// readResourceNodes reads configuration files and returns all resources
+// defined in them
+func readResourceNodes(configFiles []string, baseDir string) ([]*resourceNode, error) {
+	files, err := readConfigs(configFiles, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	var nodes []*resourceNode
+	for _, file := range files {
+		var doc yaml.Node
+		err = yaml.Unmarshal(file.data, &doc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file.path, err)
+		}
+		if len(doc.Content) == 0 {
+			// Empty file
+			continue
+		}
+		root := doc.Content[0]
+		if root.Kind != yaml.SequenceNode {
+			return nil, fmt.Errorf("%s:%d: expected a list of resources", file.path, root.Line)
+		}
+		for _, item := range root.Content {
+			nodes = append(nodes, &resourceNode{
+				Node:   item,
+				source: fmt.Sprintf("%s:%d", file.path, item.Line),
+			})
+		}
+	}
+	return nodes, nil
+}
+
+// decodeResourceNodes applies templates to the resources and decodes them
+// into out, which must be a pointer to a slice of Expected* resources
+func decodeResourceNodes(nodes []*resourceNode, templates map[string]yaml.Node, out interface{}) error {
+	slice := reflect.ValueOf(out).Elem()
+	for _, node := range nodes {
+		err := applyTemplates(node.Node, templates)
+		if err != nil {
+			return fmt.Errorf("%s: %s", node.source, err)
+		}
+		item := reflect.New(slice.Type().Elem().Elem())
+		err = node.Decode(item.Interface())
+		if err != nil {
+			return err
+		}
+		item.Interface().(IConfiguredResource).SetSource(node.source)
+		slice.Set(reflect.Append(slice, item))
+	}
+	return nil
+}
+
 func writeDiscoveryResult(collection interface{}, outputFile string) error {
 	dataBytes, err := yaml.Marshal(collection)
 	if err != nil {
@@ -144,42 +313,202 @@ func writeDiscoveryResult(collection interface{}, outputFile string) error {
 	return nil
 }
 
-// readConfigs reads all configuration files. If file doesn't exist, assumes it is
-// a glob pattern and reads all files matching the pattern.
-func readConfigs(configFiles []string, baseDir string) ([][]byte, error) {
-	var dataBytes [][]byte
+// configFileData holds the content of a configuration file
+type configFileData struct {
+	path string
+	data []byte
+}
+
+// readConfigs reads all configuration files. An entry in the list can be:
+//   - a file
+//   - a directory, all *.yaml and *.yml files inside it are read recursively
+//   - a glob pattern, `**` matches any number of directories
+//   - an exclusion pattern which starts with `!`, matching files and
+//     directories are skipped
+//
+// Files are read in the order of entries, files matched by the same entry are
+// sorted. It is an error if an entry doesn't match anything.
+func readConfigs(configFiles []string, baseDir string) ([]*configFileData, error) {
+	var paths []string
+	var exclusions []string
 	for _, configFile := range configFiles {
-		configToRead := filepath.Join(baseDir, configFile)
-		if _, err := os.Stat(configToRead); err == nil {
-			// File exists
-			if rootVerbose {
-				logger.Printf("  reading %s...\n", configToRead)
+		if strings.HasPrefix(configFile, "!") {
+			exclusions = append(exclusions, filepath.Join(baseDir, strings.TrimPrefix(configFile, "!")))
+			continue
+		}
+		matches, err := expandConfigEntry(filepath.Join(baseDir, configFile))
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			if !slices.Contains(paths, match) {
+				paths = append(paths, match)
 			}
-			data, err := os.ReadFile(configToRead)
+		}
+	}
+
+	for _, exclusion := range exclusions {
+		var kept []string
+		for _, path := range paths {
+			excluded, err := isExcluded(path, exclusion)
 			if err != nil {
 				return nil, err
 			}
-			dataBytes = append(dataBytes, data)
-		} else {
-			// File doesn't exist, assume it is a glob pattern
-			if logLevel > 0 {
-				logger.Printf("  assuming %s is a pattern...\n", configToRead)
+			if excluded {
+				if logLevel > 0 {
+					logger.Printf("  excluding %s...\n", path)
+				}
+				continue
 			}
-			files, err := filepath.Glob(configToRead)
+			kept = append(kept, path)
+		}
+		if len(kept) == len(paths) {
+			return nil, fmt.Errorf("exclusion %s doesn't match any files", exclusion)
+		}
+		paths = kept
+	}
+
+	var files []*configFileData
+	for _, path := range paths {
+		if rootVerbose {
+			logger.Printf("  reading %s...\n", path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, &configFileData{path: path, data: data})
+	}
+	return files, nil
+}
+
+// expandConfigEntry returns sorted list of files for the entry in the list of
+// configuration files
+func expandConfigEntry(entry string) ([]string, error) {
+	info, err := os.Stat(entry)
+	if err == nil {
+		if !info.IsDir() {
+			return []string{entry}, nil
+		}
+		// Directory, read all yaml files inside it
+		var matches []string
+		err = filepath.WalkDir(entry, func(path string, d fs.DirEntry, err error) error {
 			if err != nil {
-				return nil, err
+				return err
 			}
-			for _, file := range files {
-				if rootVerbose {
-					logger.Printf("  reading %s...\n", file)
-				}
-				data, err := os.ReadFile(file)
-				if err != nil {
-					return nil, err
+			ext := filepath.Ext(path)
+			if !d.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				matches = append(matches, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("directory %s doesn't contain any yaml files", entry)
+		}
+		return matches, nil
+	}
+	if !hasGlobMeta(entry) {
+		return nil, err
+	}
+
+	// File doesn't exist, assume it is a glob pattern
+	if logLevel > 0 {
+		logger.Printf("  assuming %s is a pattern...\n", entry)
+	}
+	// Walk the longest directory which doesn't contain any patterns
+	segments := strings.Split(filepath.ToSlash(entry), "/")
+	root := ""
+	for idx, segment := range segments {
+		if hasGlobMeta(segment) {
+			root = strings.Join(segments[:idx], "/")
+			break
+		}
+	}
+	if root == "" && filepath.IsAbs(entry) {
+		root = "/"
+	} else if root == "" {
+		root = "."
+	}
+	var matches []string
+	err = filepath.WalkDir(filepath.FromSlash(root), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		ok, err := matchGlob(entry, path)
+		if err != nil {
+			return err
+		}
+		if ok {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("pattern %s doesn't match any files", entry)
+	}
+	return matches, nil
+}
+
+// isExcluded checks if the file or any of its parent directories matches the
+// exclusion pattern
+func isExcluded(path string, exclusion string) (bool, error) {
+	for p := path; ; p = filepath.Dir(p) {
+		ok, err := matchGlob(exclusion, p)
+		if err != nil || ok {
+			return ok, err
+		}
+		if p == filepath.Dir(p) {
+			return false, nil
+		}
+	}
+}
+
+// hasGlobMeta reports whether path contains any of the special characters
+// recognized by filepath.Match
+func hasGlobMeta(path string) bool {
+	return strings.ContainsAny(path, `*?[\`)
+}
+
+// matchGlob reports whether name matches the pattern. In addition to
+// filepath.Match syntax, `**` matches any number of directories
+func matchGlob(pattern, name string) (bool, error) {
+	return matchGlobSegments(
+		strings.Split(filepath.ToSlash(filepath.Clean(pattern)), "/"),
+		strings.Split(filepath.ToSlash(filepath.Clean(name)), "/"),
+	)
+}
+
+func matchGlobSegments(pattern, name []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				ok, err := matchGlobSegments(pattern[1:], name[i:])
+				if err != nil || ok {
+					return ok, err
 				}
-				dataBytes = append(dataBytes, data)
 			}
+			return false, nil
+		}
+		if len(name) == 0 {
+			return false, nil
+		}
+		ok, err := filepath.Match(pattern[0], name[0])
+		if err != nil || !ok {
+			return false, err
 		}
+		pattern, name = pattern[1:], name[1:]
 	}
-	return dataBytes, nil
+	return len(name) == 0, nil
 }
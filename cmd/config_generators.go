@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Generators expand into multiple DNS records at load time. A generator
+// defines the record with ${variable} placeholders and the values of the
+// variables in `for_each`, which is either a list of variable sets:
+//
+//	- for_each:
+//	    - location: amsterdam
+//	      ip: 1.1.1.1
+//	    - location: tokyo
+//	      ip: 2.2.2.2
+//	  record:
+//	    name: ${location}
+//	    ...
+//
+// or a matrix, in which case a record is generated for every combination of
+// the values:
+//
+//	- for_each:
+//	    region: [europe, asia-pacific]
+//	    geoproximity: ["@geoproximity:amsterdam", "@geoproximity:tokyo"]
+//	  record:
+//	    name: www
+//	    region: ${region}
+//	    geoproximity: ${geoproximity}
+//	    ...
+//
+// A value which consists of a single placeholder is replaced with the value of
+// the variable as is, so it keeps its type (e.g. integer).
+
+const generatorForEachKey = "for_each"
+const generatorRecordKey = "record"
+
+var generatorVariableRe = regexp.MustCompile(`\$\{(\w+)\}`)
+
+// generatorVars maps variable names to their values
+type generatorVars map[string]*yaml.Node
+
+// expandGenerators replaces generators with the resources they generate
+func expandGenerators(nodes []*resourceNode) ([]*resourceNode, error) {
+	var expanded []*resourceNode
+	for _, node := range nodes {
+		if node.Kind != yaml.MappingNode || getMappingValue(node.Node, generatorForEachKey) == nil {
+			expanded = append(expanded, node)
+			continue
+		}
+		if len(node.Content) != 4 {
+			return nil, fmt.Errorf("line %d: generator must define only %q and %q", node.Line, generatorForEachKey, generatorRecordKey)
+		}
+		record := getMappingValue(node.Node, generatorRecordKey)
+		if record == nil || record.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("line %d: generator must define %q", node.Line, generatorRecordKey)
+		}
+		items, err := getGeneratorItems(getMappingValue(node.Node, generatorForEachKey))
+		if err != nil {
+			return nil, err
+		}
+		for _, vars := range items {
+			generated := cloneNode(record)
+			err = substituteVariables(generated, vars)
+			if err != nil {
+				return nil, err
+			}
+			expanded = append(expanded, &resourceNode{
+				Node:   generated,
+				source: node.source,
+			})
+		}
+	}
+	return expanded, nil
+}
+
+// getGeneratorItems returns variable sets, one for each generated resource
+func getGeneratorItems(forEach *yaml.Node) ([]generatorVars, error) {
+	var items []generatorVars
+	// Empty lists would silently drop the records, e.g. after a typo
+	if (forEach.Kind == yaml.SequenceNode || forEach.Kind == yaml.MappingNode) && len(forEach.Content) == 0 {
+		return nil, fmt.Errorf("line %d: %q is empty", forEach.Line, generatorForEachKey)
+	}
+	switch forEach.Kind {
+	case yaml.SequenceNode:
+		for _, el := range forEach.Content {
+			if el.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("line %d: expected a map of variables", el.Line)
+			}
+			vars := make(generatorVars)
+			for i := 0; i+1 < len(el.Content); i += 2 {
+				vars[el.Content[i].Value] = el.Content[i+1]
+			}
+			items = append(items, vars)
+		}
+	case yaml.MappingNode:
+		// Matrix, the first variable changes the slowest
+		items = []generatorVars{{}}
+		for i := 0; i+1 < len(forEach.Content); i += 2 {
+			name := forEach.Content[i].Value
+			values := forEach.Content[i+1]
+			if values.Kind != yaml.SequenceNode {
+				return nil, fmt.Errorf("line %d: expected a list of values for %q", values.Line, name)
+			}
+			if len(values.Content) == 0 {
+				return nil, fmt.Errorf("line %d: list of values for %q is empty", values.Line, name)
+			}
+			var product []generatorVars
+			for _, vars := range items {
+				for _, value := range values.Content {
+					newVars := make(generatorVars)
+					for k, v := range vars {
+						newVars[k] = v
+					}
+					newVars[name] = value
+					product = append(product, newVars)
+				}
+			}
+			items = product
+		}
+	default:
+		return nil, fmt.Errorf("line %d: %q must be a list or a map", forEach.Line, generatorForEachKey)
+	}
+	return items, nil
+}
+
+// substituteVariables replaces ${variable} placeholders in the node
+func substituteVariables(node *yaml.Node, vars generatorVars) error {
+	if node.Kind != yaml.ScalarNode {
+		for _, child := range node.Content {
+			err := substituteVariables(child, vars)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if match := generatorVariableRe.FindStringSubmatch(node.Value); match != nil && match[0] == node.Value {
+		value, ok := vars[match[1]]
+		if !ok {
+			return fmt.Errorf("line %d: unknown variable %q", node.Line, match[1])
+		}
+		*node = *cloneNode(value)
+		return nil
+	}
+
+	var err error
+	node.Value = generatorVariableRe.ReplaceAllStringFunc(node.Value, func(s string) string {
+		name := generatorVariableRe.FindStringSubmatch(s)[1]
+		value, ok := vars[name]
+		if !ok {
+			err = fmt.Errorf("line %d: unknown variable %q", node.Line, name)
+			return s
+		}
+		if value.Kind != yaml.ScalarNode {
+			err = fmt.Errorf("line %d: variable %q must be a scalar to be used inside a string", node.Line, name)
+			return s
+		}
+		return value.Value
+	})
+	return err
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestGetConfig_generators_matrix(t *testing.T) {
+	configFile := writeTestConfig(t, map[string]string{
+		"main.yaml": `
+constellix:
+  dns:
+    example.com:
+      - records.yaml
+`,
+		"records.yaml": `
+- name: static
+  type: A
+  mode: standard
+  value:
+    - value: 1.1.1.1
+      enabled: true
+- for_each:
+    region: [europe, asia-pacific]
+    geoproximity: [10, 20]
+  record:
+    name: www
+    type: A
+    mode: standard
+    region: ${region}
+    geoproximity: ${geoproximity}
+    notes: generated for ${region}
+    value:
+      - value: 1.1.1.1
+        enabled: true
+`,
+	})
+	config, err := getConfig(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := config.DNS["example.com"]
+	expected := []string{
+		`A "static" (, 0)`,
+		`A "www" (europe, 10)`,
+		`A "www" (europe, 20)`,
+		`A "www" (asia-pacific, 10)`,
+		`A "www" (asia-pacific, 20)`,
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("expected %d records, got %d", len(expected), len(records))
+	}
+	for idx, record := range records {
+		if record.GetResourceID() != expected[idx] {
+			t.Errorf("record %d: expected %q, got %q", idx, expected[idx], record.GetResourceID())
+		}
+	}
+	if records[3].Notes != "generated for asia-pacific" {
+		t.Errorf("expected %q, got %q", "generated for asia-pacific", records[3].Notes)
+	}
+	if _, ok := records[1].definedFieldsMap["geoproximity"]; !ok {
+		t.Errorf("expected %q to be defined, got %v", "geoproximity", records[1].definedFieldsMap)
+	}
+}
+
+func TestGetConfig_generators_list(t *testing.T) {
+	configFile := writeTestConfig(t, map[string]string{
+		"main.yaml": `
+constellix:
+  dns:
+    example.com:
+      - records.yaml
+`,
+		"records.yaml": `
+- for_each:
+    - location: amsterdam
+      ip: 1.1.1.1
+    - location: tokyo
+      ip: 2.2.2.2
+  record:
+    name: ${location}
+    type: A
+    mode: standard
+    value:
+      - value: ${ip}
+        enabled: true
+`,
+	})
+	config, err := getConfig(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := config.DNS["example.com"]
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	value, ok := records[1].Value.([]*DNSStandardItemValue)
+	if records[1].Name != "tokyo" || !ok || value[0].Value != "2.2.2.2" {
+		t.Errorf("unexpected record %q with value %v", records[1].Name, records[1].Value)
+	}
+}
+
+func TestGetConfig_generators_unknown_variable(t *testing.T) {
+	configFile := writeTestConfig(t, map[string]string{
+		"main.yaml": `
+constellix:
+  dns:
+    example.com:
+      - records.yaml
+`,
+		"records.yaml": `
+- for_each:
+    - location: amsterdam
+  record:
+    name: ${loaction}
+    type: A
+`,
+	})
+	_, err := getConfig(configFile)
+	expected := "line 5: unknown variable \"loaction\""
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+}
+
+func TestGetConfig_generators_empty(t *testing.T) {
+	tests := map[string]string{
+		"for_each: []":                         "line 2: \"for_each\" is empty",
+		"for_each: {}":                         "line 2: \"for_each\" is empty",
+		"for_each: {region: [europe], ip: []}": "line 2: list of values for \"ip\" is empty",
+	}
+	for forEach, expected := range tests {
+		configFile := writeTestConfig(t, map[string]string{
+			"main.yaml": `
+constellix:
+  dns:
+    example.com:
+      - records.yaml
+`,
+			"records.yaml": `
+- ` + forEach + `
+  record:
+    name: www
+    type: A
+`,
+		})
+		_, err := getConfig(configFile)
+		if err == nil || err.Error() != expected {
+			t.Errorf("%s: expected error %q, got %v", forEach, expected, err)
+		}
+	}
+}
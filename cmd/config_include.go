@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	"gopkg.in/yaml.v3"
+)
+
+// The main configuration file can include other main configuration files
+// (fragments), e.g. one per team or per domain:
+//
+//	include:
+//	  - teams/*.yaml
+//	constellix:
+//	  ...
+//
+// Fragments have the same format as the main configuration file and can
+// include other fragments. Paths in a fragment are relative to the fragment
+// itself. Lists of configuration files are concatenated, but a domain or a
+// template can be defined only in one of the fragments.
+
+// mainConfigLoader reads the main configuration file with all included
+// fragments and merges them
+type mainConfigLoader struct {
+	// Directory of the main configuration file, all paths are rebased to it
+	baseDir string
+	config  MainConfig
+	// Files which defined domains and templates, used for conflict detection
+	domainSources   map[string]string
+	templateSources map[string]string
+	// Files which are being loaded, used to detect include cycles
+	loading []string
+}
+
+// readMainConfig reads the main configuration file and all fragments it includes
+func readMainConfig(configFile string) (*MainConfig, error) {
+	loader := mainConfigLoader{
+		baseDir:         filepath.Dir(configFile),
+		domainSources:   make(map[string]string),
+		templateSources: make(map[string]string),
+	}
+	loader.config.Constellix.DNS = make(map[string][]string)
+	loader.config.Constellix.Templates = make(map[string]yaml.Node)
+
+	if logLevel > 0 {
+		logger.Printf("Reading configuration file %s...\n", configFile)
+	}
+	dataBytes, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+	err = loader.load(configFile, dataBytes)
+	if err != nil {
+		return nil, err
+	}
+	return &loader.config, nil
+}
+
+func (l *mainConfigLoader) load(configFile string, dataBytes []byte) error {
+	configFile = filepath.Clean(configFile)
+	if slices.Contains(l.loading, configFile) {
+		return fmt.Errorf("%s: include cycle detected", configFile)
+	}
+	l.loading = append(l.loading, configFile)
+	defer func() {
+		l.loading = l.loading[:len(l.loading)-1]
+	}()
+
+	var fragment MainConfig
+	err := yaml.Unmarshal(dataBytes, &fragment)
+	if err != nil {
+		return fmt.Errorf("%s: %s", configFile, err)
+	}
+
+	fragmentDir := filepath.Dir(configFile)
+	relDir, err := filepath.Rel(l.baseDir, fragmentDir)
+	if err != nil {
+		return err
+	}
+	rebase := func(entries []string) []string {
+		rebased := make([]string, len(entries))
+		for idx, entry := range entries {
+			if strings.HasPrefix(entry, "!") {
+				rebased[idx] = "!" + filepath.Join(relDir, strings.TrimPrefix(entry, "!"))
+			} else {
+				rebased[idx] = filepath.Join(relDir, entry)
+			}
+		}
+		return rebased
+	}
+
+	constellix := &l.config.Constellix
+	constellix.Sonar.HTTPChecksConfigFiles = append(
+		constellix.Sonar.HTTPChecksConfigFiles, rebase(fragment.Constellix.Sonar.HTTPChecksConfigFiles)...,
+	)
+	constellix.Sonar.TCPChecksConfigFiles = append(
+		constellix.Sonar.TCPChecksConfigFiles, rebase(fragment.Constellix.Sonar.TCPChecksConfigFiles)...,
+	)
+	constellix.GeoProximityConfigFiles = append(
+		constellix.GeoProximityConfigFiles, rebase(fragment.Constellix.GeoProximityConfigFiles)...,
+	)
+	constellix.PoolConfigFiles = append(
+		constellix.PoolConfigFiles, rebase(fragment.Constellix.PoolConfigFiles)...,
+	)
+	constellix.IPFilterConfigFiles = append(
+		constellix.IPFilterConfigFiles, rebase(fragment.Constellix.IPFilterConfigFiles)...,
+	)
+	constellix.DomainConfigFiles = append(
+		constellix.DomainConfigFiles, rebase(fragment.Constellix.DomainConfigFiles)...,
+	)
+	for domainName, entries := range fragment.Constellix.DNS {
+		if source, ok := l.domainSources[domainName]; ok {
+			return fmt.Errorf("domain %s is defined in both %s and %s", domainName, source, configFile)
+		}
+		l.domainSources[domainName] = configFile
+		constellix.DNS[domainName] = rebase(entries)
+	}
+	for name, template := range fragment.Constellix.Templates {
+		if source, ok := l.templateSources[name]; ok {
+			return fmt.Errorf("template %q is defined in both %s and %s", name, source, configFile)
+		}
+		l.templateSources[name] = configFile
+		constellix.Templates[name] = template
+	}
+
+	includes, err := readConfigs(fragment.Include, fragmentDir)
+	if err != nil {
+		return fmt.Errorf("%s: %s", configFile, err)
+	}
+	for _, include := range includes {
+		if logLevel > 0 {
+			logger.Printf("Including configuration file %s...\n", include.path)
+		}
+		err = l.load(include.path, include.data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
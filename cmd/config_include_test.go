@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetConfig_include(t *testing.T) {
+	configFile := writeTestConfig(t, map[string]string{
+		"main.yaml": `
+include:
+  - teams/*/main.yaml
+  - teams/geo.yaml
+constellix:
+  templates:
+    record:
+      mode: standard
+  geoproximity:
+    - geo.yaml
+`,
+		"geo.yaml": `
+- name: amsterdam
+  latitude: 52.37
+  longitude: 4.89
+`,
+		"teams/web/main.yaml": `
+constellix:
+  dns:
+    example.com:
+      - records.yaml
+`,
+		"teams/web/records.yaml": `
+- name: www
+  type: A
+  extends: record
+  value:
+    - value: 1.1.1.1
+      enabled: true
+`,
+		"teams/geo.yaml": `
+constellix:
+  geoproximity:
+    - geo/*.yaml
+`,
+		"teams/geo/tokyo.yaml": `
+- name: tokyo
+  latitude: 35.68
+  longitude: 139.69
+`,
+	})
+	config, err := getConfig(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.DNS["example.com"]) != 1 {
+		t.Errorf("expected 1 record, got %d", len(config.DNS["example.com"]))
+	}
+	if len(config.GeoProximities) != 2 {
+		t.Fatalf("expected 2 geoproximities, got %d", len(config.GeoProximities))
+	}
+	if config.GeoProximities[1].Name != "tokyo" {
+		t.Errorf("expected %q, got %q", "tokyo", config.GeoProximities[1].Name)
+	}
+}
+
+func TestGetConfig_include_domain_conflict(t *testing.T) {
+	configFile := writeTestConfig(t, map[string]string{
+		"main.yaml": `
+include:
+  - team.yaml
+constellix:
+  dns:
+    example.com:
+      - records.yaml
+`,
+		"team.yaml": `
+constellix:
+  dns:
+    example.com:
+      - team-records.yaml
+`,
+	})
+	_, err := getConfig(configFile)
+	if err == nil || !strings.HasPrefix(err.Error(), "domain example.com is defined in both") {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+}
+
+func TestGetConfig_include_domain_settings_conflict(t *testing.T) {
+	configFile := writeTestConfig(t, map[string]string{
+		"main.yaml": `
+include:
+  - team/main.yaml
+constellix:
+  domains:
+    - domains.yaml
+`,
+		"domains.yaml": `
+- name: example.com
+  gtd: true
+`,
+		"team/main.yaml": `
+constellix:
+  domains:
+    - domains.yaml
+`,
+		"team/domains.yaml": `
+- name: example.com
+  gtd: false
+`,
+	})
+	_, err := getConfig(configFile)
+	if err == nil || !strings.HasPrefix(err.Error(), "settings of domain example.com are defined in both") {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+}
+
+func TestGetConfig_include_cycle(t *testing.T) {
+	configFile := writeTestConfig(t, map[string]string{
+		"main.yaml": `
+include:
+  - team.yaml
+`,
+		"team.yaml": `
+include:
+  - main.yaml
+`,
+	})
+	_, err := getConfig(configFile)
+	if err == nil || !strings.HasSuffix(err.Error(), "main.yaml: include cycle detected") {
+		t.Errorf("expected cycle error, got %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Overlays patch resources from the main configuration, e.g. to describe the
+// differences between staging and production environments. An overlay file
+// mirrors the structure of the main configuration file, but lists resources
+// inline instead of configuration files:
+//
+//	constellix:
+//	  sonar:
+//	    http_checks:
+//	      - name: prod
+//	        host: 2.2.2.2
+//	  dns:
+//	    surfly.gratis:
+//	      - type: A
+//	        name: www
+//	        region: default
+//	        ttl: 300
+//	      - type: A
+//	        name: old
+//	        remove: true
+//
+// Resources are matched by their resource ID. Fields of a matched resource are
+// replaced with the fields from the overlay, a resource which doesn't match
+// anything is added and `remove: true` removes the matched resource.
+
+const overlayRemoveKey = "remove"
+
+type OverlayConfig struct {
+	Constellix struct {
+		Sonar struct {
+			HTTPChecks []yaml.Node `yaml:"http_checks"`
+			TCPChecks  []yaml.Node `yaml:"tcp_checks"`
+		} `yaml:"sonar"`
+		GeoProximities []yaml.Node            `yaml:"geoproximity"`
+		Pools          []yaml.Node            `yaml:"pools"`
+		IPFilters      []yaml.Node            `yaml:"ipfilters"`
+		Domains        []yaml.Node            `yaml:"domains"`
+		DNS            map[string][]yaml.Node `yaml:"dns"`
+	} `yaml:"constellix"`
+	fileName string
+}
+
+// nodeIDFunc returns resource ID of the resource defined in yaml node
+type nodeIDFunc func(node *yaml.Node) (string, error)
+
+// readOverlays reads overlay files in the specified order
+func readOverlays(overlayFiles []string) ([]*OverlayConfig, error) {
+	var overlays []*OverlayConfig
+	for _, overlayFile := range overlayFiles {
+		if logLevel > 0 {
+			logger.Printf("Reading overlay file %s...\n", overlayFile)
+		}
+		dataBytes, err := os.ReadFile(overlayFile)
+		if err != nil {
+			return nil, err
+		}
+		overlay := OverlayConfig{fileName: overlayFile}
+		err = yaml.Unmarshal(dataBytes, &overlay)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", overlayFile, err)
+		}
+		overlays = append(overlays, &overlay)
+	}
+	return overlays, nil
+}
+
+// applyOverlay patches resources with the resources from the overlay and
+// returns the resulting list of resources
+func applyOverlay(nodes []*resourceNode, patches []yaml.Node, overlayFile string, templates map[string]yaml.Node, idFunc nodeIDFunc) ([]*resourceNode, error) {
+	if len(patches) == 0 {
+		return nodes, nil
+	}
+	ids := make([]string, len(nodes))
+	for idx, node := range nodes {
+		id, err := getNodeResourceID(node.Node, templates, idFunc)
+		if err != nil {
+			return nil, err
+		}
+		ids[idx] = id
+	}
+
+	for idx := range patches {
+		patch := cloneNode(&patches[idx])
+		if patch.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("line %d: expected a resource", patch.Line)
+		}
+		remove, err := popRemove(patch)
+		if err != nil {
+			return nil, err
+		}
+		id, err := getNodeResourceID(patch, templates, idFunc)
+		if err != nil {
+			return nil, err
+		}
+
+		matched := -1
+		for i := range ids {
+			if ids[i] == id {
+				matched = i
+				break
+			}
+		}
+
+		switch {
+		case remove && matched == -1:
+			return nil, fmt.Errorf("unable to remove resource %q: not found", id)
+		case remove:
+			if logLevel > 0 {
+				logger.Printf("  overlay removes %q\n", id)
+			}
+			nodes = append(nodes[:matched], nodes[matched+1:]...)
+			ids = append(ids[:matched], ids[matched+1:]...)
+		case matched == -1:
+			if logLevel > 0 {
+				logger.Printf("  overlay adds %q\n", id)
+			}
+			nodes = append(nodes, &resourceNode{
+				Node:   patch,
+				source: fmt.Sprintf("%s:%d", overlayFile, patch.Line),
+			})
+			ids = append(ids, id)
+		default:
+			if logLevel > 0 {
+				logger.Printf("  overlay patches %q\n", id)
+			}
+			patched := cloneNode(nodes[matched].Node)
+			for i := 0; i+1 < len(patch.Content); i += 2 {
+				setMappingValue(patched, patch.Content[i], patch.Content[i+1])
+			}
+			nodes[matched] = &resourceNode{
+				Node:   patched,
+				source: fmt.Sprintf("%s, %s:%d", nodes[matched].source, overlayFile, patch.Line),
+			}
+		}
+	}
+	return nodes, nil
+}
+
+// getNodeResourceID returns resource ID of the resource with applied
+// templates. The node itself is not modified
+func getNodeResourceID(node *yaml.Node, templates map[string]yaml.Node, idFunc nodeIDFunc) (string, error) {
+	resolved := cloneNode(node)
+	err := applyTemplates(resolved, templates)
+	if err != nil {
+		return "", err
+	}
+	return idFunc(resolved)
+}
+
+// popRemove removes `remove` key from the mapping node and returns its value
+func popRemove(node *yaml.Node) (bool, error) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != overlayRemoveKey {
+			continue
+		}
+		var remove bool
+		err := node.Content[i+1].Decode(&remove)
+		if err != nil {
+			return false, err
+		}
+		node.Content = append(node.Content[:i], node.Content[i+2:]...)
+		return remove, nil
+	}
+	return false, nil
+}
+
+// setMappingValue sets the value for the key in the mapping node
+func setMappingValue(node *yaml.Node, key *yaml.Node, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key.Value {
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content, key, value)
+}
+
+func sonarHTTPCheckNodeID(node *yaml.Node) (string, error) {
+	var s SonarHTTPCheck
+	err := node.Decode(&s)
+	if err != nil {
+		return "", err
+	}
+	return s.GetResourceID(), nil
+}
+
+func sonarTCPCheckNodeID(node *yaml.Node) (string, error) {
+	var s SonarTCPCheck
+	err := node.Decode(&s)
+	if err != nil {
+		return "", err
+	}
+	return s.GetResourceID(), nil
+}
+
+func geoProximityNodeID(node *yaml.Node) (string, error) {
+	var s GeoProximity
+	err := node.Decode(&s)
+	if err != nil {
+		return "", err
+	}
+	return s.GetResourceID(), nil
+}
+
+func ipFilterNodeID(node *yaml.Node) (string, error) {
+	var s IPFilter
+	err := node.Decode(&s)
+	if err != nil {
+		return "", err
+	}
+	return s.GetResourceID(), nil
+}
+
+func dnsDomainNodeID(node *yaml.Node) (string, error) {
+	var s DNSDomain
+	err := node.Decode(&s)
+	if err != nil {
+		return "", err
+	}
+	return s.GetResourceID(), nil
+}
+
+// poolNodeID doesn't parse values of the pool, so Sonar checks are not
+// resolved
+func poolNodeID(node *yaml.Node) (string, error) {
+	var s struct {
+		Name string `yaml:"name"`
+		Type string `yaml:"type"`
+	}
+	err := node.Decode(&s)
+	if err != nil {
+		return "", err
+	}
+	return (&Pool{Name: s.Name, Type: s.Type}).GetResourceID(), nil
+}
+
+// dnsRecordNodeID doesn't parse the value of the record, so it can be used
+// with partially defined records. References to geoproximities are matched as
+// they are written, so they are not looked up in Constellix
+func dnsRecordNodeID(node *yaml.Node) (string, error) {
+	var s DNSRecord
+	err := node.Decode(&s)
+	if err != nil {
+		return "", err
+	}
+	if reference, ok := s.GeoProximity.(string); ok {
+		return fmt.Sprintf("%s %q (%s, %s)", s.Type, s.Name, s.Region, reference), nil
+	}
+	return s.GetResourceID(), nil
+}
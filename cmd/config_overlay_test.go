@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestGetConfig_overlay_dns_records(t *testing.T) {
+	configFile := writeTestConfig(t, map[string]string{
+		"main.yaml": `
+constellix:
+  templates:
+    eu-record:
+      region: europe
+      mode: standard
+      enabled: true
+  dns:
+    example.com:
+      - records.yaml
+`,
+		"records.yaml": `
+- name: www
+  type: A
+  extends: eu-record
+  ttl: 60
+  value:
+    - value: 1.1.1.1
+      enabled: true
+- name: old
+  type: A
+  extends: eu-record
+  value:
+    - value: 1.1.1.2
+      enabled: true
+`,
+		"prod.yaml": `
+constellix:
+  dns:
+    example.com:
+      - name: www
+        type: A
+        region: europe
+        ttl: 300
+      - name: old
+        type: A
+        extends: eu-record
+        remove: true
+      - name: new
+        type: A
+        extends: eu-record
+        value:
+          - value: 1.1.1.3
+            enabled: true
+`,
+	})
+	config, err := getConfig(configFile, filepath.Join(filepath.Dir(configFile), "prod.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := config.DNS["example.com"]
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[0].Name != "www" || records[0].TTL != 300 {
+		t.Errorf("expected patched www record with ttl 300, got %q with ttl %d", records[0].Name, records[0].TTL)
+	}
+	value, ok := records[0].Value.([]*DNSStandardItemValue)
+	if !ok || len(value) != 1 || value[0].Value != "1.1.1.1" {
+		t.Errorf("expected value to be preserved, got %v", records[0].Value)
+	}
+	if records[1].Name != "new" || records[1].Region != "europe" {
+		t.Errorf("expected new record in europe, got %q in %q", records[1].Name, records[1].Region)
+	}
+}
+
+func TestGetConfig_overlay_remove_missing(t *testing.T) {
+	configFile := writeTestConfig(t, map[string]string{
+		"main.yaml": `
+constellix:
+  geoproximity:
+    - geo.yaml
+`,
+		"geo.yaml": `
+- name: amsterdam
+  latitude: 52.37
+  longitude: 4.89
+`,
+		"prod.yaml": `
+constellix:
+  geoproximity:
+    - name: paris
+      remove: true
+`,
+	})
+	overlayFile := filepath.Join(filepath.Dir(configFile), "prod.yaml")
+	_, err := getConfig(configFile, overlayFile)
+	expected := overlayFile + ": unable to remove resource \"paris\": not found"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+}
+
+func TestGetConfig_overlay_new_domain(t *testing.T) {
+	configFile := writeTestConfig(t, map[string]string{
+		"main.yaml": `
+constellix:
+  dns:
+    example.com:
+      - records.yaml
+`,
+		"records.yaml": `
+- name: www
+  type: CNAME
+  mode: standard
+  value:
+    - value: example.com.
+      enabled: true
+`,
+		"staging.yaml": `
+constellix:
+  dns:
+    staging.example.com:
+      - name: www
+        type: CNAME
+        mode: standard
+        value:
+          - value: staging.example.com.
+            enabled: true
+`,
+	})
+	config, err := getConfig(configFile, filepath.Join(filepath.Dir(configFile), "staging.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.DNS) != 2 {
+		t.Fatalf("expected 2 domains, got %d", len(config.DNS))
+	}
+	if len(config.DNS["staging.example.com"]) != 1 {
+		t.Errorf("expected 1 record, got %d", len(config.DNS["staging.example.com"]))
+	}
+}
+
+func TestDNSRecordNodeID_geoproximity_reference(t *testing.T) {
+	oldGetGeoProximities := GetGeoProximities
+	defer func() { GetGeoProximities = oldGetGeoProximities }()
+	GetGeoProximities = func() ([]*GeoProximity, error) {
+		t.Fatal("geoproximities must not be retrieved")
+		return nil, nil
+	}
+
+	var node yaml.Node
+	err := yaml.Unmarshal([]byte(`{name: www, type: A, region: europe, geoproximity: "@geoproximity:amsterdam"}`), &node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := dnsRecordNodeID(node.Content[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `A "www" (europe, @geoproximity:amsterdam)`
+	if id != expected {
+		t.Errorf("expected %q, got %q", expected, id)
+	}
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"golang.org/x/exp/maps"
+	"gopkg.in/yaml.v3"
+)
+
+var supportedRenderFormats = []string{"yaml", "json"}
+
+// renderSourceKey is used to include location of the resource in JSON output.
+// In YAML output it is added as a comment
+const renderSourceKey = "_source"
+
+// renderConfig returns fully resolved configuration: only fields which are
+// synchronized with Constellix, with all references resolved. The structure
+// follows the main configuration file
+func renderConfig(config *Config, format string, withSources bool) ([]byte, error) {
+	jsonSources := withSources && format == "json"
+	yamlSources := withSources && format == "yaml"
+
+	httpChecks, err := renderResources(config.SonarHTTPChecks, jsonSources, yamlSources)
+	if err != nil {
+		return nil, err
+	}
+	tcpChecks, err := renderResources(config.SonarTCPChecks, jsonSources, yamlSources)
+	if err != nil {
+		return nil, err
+	}
+	sonar := &yaml.Node{Kind: yaml.MappingNode}
+	appendMappingItem(sonar, "http_checks", httpChecks)
+	appendMappingItem(sonar, "tcp_checks", tcpChecks)
+
+	geops, err := renderResources(config.GeoProximities, jsonSources, yamlSources)
+	if err != nil {
+		return nil, err
+	}
+
+	pools, err := renderResources(config.Pools, jsonSources, yamlSources)
+	if err != nil {
+		return nil, err
+	}
+
+	ipFilters, err := renderResources(config.IPFilters, jsonSources, yamlSources)
+	if err != nil {
+		return nil, err
+	}
+
+	domains, err := renderResources(config.Domains, jsonSources, yamlSources)
+	if err != nil {
+		return nil, err
+	}
+
+	dns := &yaml.Node{Kind: yaml.MappingNode}
+	domainNames := maps.Keys(config.DNS)
+	sort.Strings(domainNames)
+	for _, domainName := range domainNames {
+		records, err := renderResources(config.DNS[domainName], jsonSources, yamlSources)
+		if err != nil {
+			return nil, err
+		}
+		appendMappingItem(dns, domainName, records)
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	appendMappingItem(root, "sonar", sonar)
+	appendMappingItem(root, "geoproximity", geops)
+	appendMappingItem(root, "pools", pools)
+	appendMappingItem(root, "ipfilters", ipFilters)
+	appendMappingItem(root, "domains", domains)
+	appendMappingItem(root, "dns", dns)
+
+	switch format {
+	case "yaml":
+		return yaml.Marshal(root)
+	case "json":
+		var data interface{}
+		err = root.Decode(&data)
+		if err != nil {
+			return nil, err
+		}
+		return json.MarshalIndent(data, "", "  ")
+	default:
+		return nil, fmt.Errorf("unsupported format: got %q, want one of %q", format, supportedRenderFormats)
+	}
+}
+
+// renderResources renders a collection of Expected* resources
+func renderResources(collection interface{}, jsonSources, yamlSources bool) (*yaml.Node, error) {
+	seq := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, item := range toResourceMatcher(collection) {
+		resource := item.(IExpectedResource)
+		fields := renderResource(resource)
+		source := resource.(IConfiguredResource).GetSource()
+		if jsonSources {
+			fields[renderSourceKey] = source
+		}
+		node := &yaml.Node{}
+		err := node.Encode(fields)
+		if err != nil {
+			return nil, err
+		}
+		if yamlSources {
+			node.HeadComment = "source: " + source
+		}
+		seq.Content = append(seq.Content, node)
+	}
+	return seq, nil
+}
+
+// renderResource returns fields of the resource which are defined in
+// configuration, using JSON keys
+func renderResource(resource IExpectedResource) map[string]interface{} {
+	value := reflect.Indirect(reflect.ValueOf(resource.GetResource()))
+	fields := make(map[string]interface{})
+	for _, structFieldName := range resource.GetDefinedStructFieldNames() {
+		key := getTag(value.Type(), structFieldName, "json")
+		fields[key] = value.FieldByName(structFieldName).Interface()
+	}
+	return fields
+}
+
+// appendMappingItem appends key and value to the mapping node
+func appendMappingItem(node *yaml.Node, key string, value *yaml.Node) {
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+}
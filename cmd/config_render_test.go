@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var renderTestFiles = map[string]string{
+	"main.yaml": `
+constellix:
+  templates:
+    record:
+      ttl: 60
+  dns:
+    example.com:
+      - records.yaml
+`,
+	"records.yaml": `
+- name: www
+  type: A
+  mode: standard
+  extends: record
+  value:
+    - value: 1.1.1.1
+      enabled: true
+`,
+}
+
+func TestRenderConfig_yaml(t *testing.T) {
+	configFile := writeTestConfig(t, renderTestFiles)
+	config, err := getConfig(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := renderConfig(config, "yaml", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := filepath.Join(filepath.Dir(configFile), "records.yaml") + ":2"
+	expected := `sonar:
+    http_checks: []
+    tcp_checks: []
+geoproximity: []
+pools: []
+ipfilters: []
+domains: []
+dns:
+    example.com:
+        # source: ` + source + `
+        - mode: standard
+          name: www
+          ttl: 60
+          type: A
+          value:
+            - value: 1.1.1.1
+              enabled: true
+`
+	if string(data) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, string(data))
+	}
+}
+
+func TestRenderConfig_json(t *testing.T) {
+	configFile := writeTestConfig(t, renderTestFiles)
+	config, err := getConfig(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := renderConfig(config, "json", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rendered struct {
+		DNS map[string][]map[string]interface{} `json:"dns"`
+	}
+	err = json.Unmarshal(data, &rendered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := rendered.DNS["example.com"]
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	if records[0]["ttl"] != float64(60) {
+		t.Errorf("expected ttl 60, got %v", records[0]["ttl"])
+	}
+	if _, ok := records[0]["region"]; ok {
+		t.Errorf("expected undefined field %q to be omitted", "region")
+	}
+	source, _ := records[0][renderSourceKey].(string)
+	if !strings.HasSuffix(source, "records.yaml:2") {
+		t.Errorf("unexpected source %q", source)
+	}
+}
+
+func TestRenderConfig_unsupported_format(t *testing.T) {
+	_, err := renderConfig(&Config{}, "toml", false)
+	if err == nil || !strings.HasPrefix(err.Error(), "unsupported format: got \"toml\"") {
+		t.Errorf("expected unsupported format error, got %v", err)
+	}
+}
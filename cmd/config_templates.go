@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Templates are defined in the main configuration file and can be referenced
+// by any resource with `extends: <name>`. Fields of the template are merged
+// into the resource before it is decoded, so inherited fields are treated as
+// defined and are synchronized with the remote. Fields of the resource itself
+// always take precedence. Templates can extend other templates.
+
+const extendsKey = "extends"
+
+// applyTemplates merges fields from the template referenced by `extends` key
+// into the node and removes `extends` key from it
+func applyTemplates(node *yaml.Node, templates map[string]yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	name, ok, err := popExtends(node)
+	if err != nil || !ok {
+		return err
+	}
+	fields, err := resolveTemplate(name, templates, nil)
+	if err != nil {
+		return err
+	}
+	mergeMissingFields(node, fields)
+	return nil
+}
+
+// resolveTemplate returns the fields of the template, including fields from
+// templates it extends
+func resolveTemplate(name string, templates map[string]yaml.Node, seen []string) (*yaml.Node, error) {
+	for _, s := range seen {
+		if s == name {
+			return nil, fmt.Errorf("template %q extends itself", name)
+		}
+	}
+	tmpl, ok := templates[name]
+	if !ok {
+		return nil, fmt.Errorf("template %q is not defined", name)
+	}
+	if tmpl.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("template %q must be a map", name)
+	}
+	fields := cloneNode(&tmpl)
+	parent, ok, err := popExtends(fields)
+	if err != nil {
+		return nil, fmt.Errorf("template %q: %s", name, err)
+	}
+	if ok {
+		parentFields, err := resolveTemplate(parent, templates, append(seen, name))
+		if err != nil {
+			return nil, err
+		}
+		mergeMissingFields(fields, parentFields)
+	}
+	return fields, nil
+}
+
+// popExtends removes `extends` key from the mapping node and returns its value
+func popExtends(node *yaml.Node) (string, bool, error) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != extendsKey {
+			continue
+		}
+		valueNode := node.Content[i+1]
+		if valueNode.Kind != yaml.ScalarNode || valueNode.Value == "" {
+			return "", false, fmt.Errorf("line %d: %q must be a template name", valueNode.Line, extendsKey)
+		}
+		node.Content = append(node.Content[:i], node.Content[i+2:]...)
+		return valueNode.Value, true, nil
+	}
+	return "", false, nil
+}
+
+// mergeMissingFields copies fields from src mapping node which are not
+// defined in dst mapping node
+func mergeMissingFields(dst *yaml.Node, src *yaml.Node) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		if getMappingValue(dst, src.Content[i].Value) != nil {
+			continue
+		}
+		dst.Content = append(dst.Content, cloneNode(src.Content[i]), cloneNode(src.Content[i+1]))
+	}
+}
+
+// getMappingValue returns value node for the key in the mapping node
+func getMappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// cloneNode returns a deep copy of the yaml node
+func cloneNode(node *yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	c := *node
+	c.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		c.Content[i] = cloneNode(child)
+	}
+	return &c
+}
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestConfig writes files to a temporary directory and returns path to
+// the main configuration file
+func writeTestConfig(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, "main.yaml")
+}
+
+func TestGetConfig_templates_http_checks(t *testing.T) {
+	configFile := writeTestConfig(t, map[string]string{
+		"main.yaml": `
+constellix:
+  templates:
+    default-check:
+      ipVersion: IPV4
+      port: 443
+      protocolType: HTTPS
+      interval: ONEMINUTE
+      checkSites: [1, 2]
+      sslPolicy: IGNORE
+  sonar:
+    http_checks:
+      - checks.yaml
+`,
+		"checks.yaml": `
+- name: prod
+  extends: default-check
+  host: 1.1.1.1
+  sslPolicy: FAIL
+`,
+	})
+	config, err := getConfig(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.SonarHTTPChecks) != 1 {
+		t.Fatalf("expected 1 check, got %d", len(config.SonarHTTPChecks))
+	}
+	check := config.SonarHTTPChecks[0]
+	if check.Interval != "ONEMINUTE" {
+		t.Errorf("expected %q, got %q", "ONEMINUTE", check.Interval)
+	}
+	if check.SSLPolicy != "FAIL" {
+		t.Errorf("expected %q, got %q", "FAIL", check.SSLPolicy)
+	}
+	for _, key := range []string{"name", "host", "interval", "checkSites", "sslPolicy"} {
+		if _, ok := check.definedFieldsMap[key]; !ok {
+			t.Errorf("expected %q to be defined, got %v", key, check.definedFieldsMap)
+		}
+	}
+	if _, ok := check.definedFieldsMap["extends"]; ok {
+		t.Errorf("expected %q not to be defined", "extends")
+	}
+}
+
+func TestGetConfig_templates_dns_records_chained(t *testing.T) {
+	configFile := writeTestConfig(t, map[string]string{
+		"main.yaml": `
+constellix:
+  templates:
+    record:
+      ttl: 60
+      enabled: true
+    eu-record:
+      extends: record
+      region: europe
+  dns:
+    example.com:
+      - records.yaml
+`,
+		"records.yaml": `
+- name: www
+  type: A
+  mode: standard
+  extends: eu-record
+  ttl: 300
+  value:
+    - value: 1.1.1.1
+      enabled: true
+`,
+	})
+	config, err := getConfig(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := config.DNS["example.com"]
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	record := records[0]
+	if record.TTL != 300 {
+		t.Errorf("expected %d, got %d", 300, record.TTL)
+	}
+	if record.Region != "europe" || !record.Enabled {
+		t.Errorf("expected inherited region and enabled, got %q and %t", record.Region, record.Enabled)
+	}
+	if _, ok := record.definedFieldsMap["region"]; !ok {
+		t.Errorf("expected %q to be defined, got %v", "region", record.definedFieldsMap)
+	}
+}
+
+func TestGetConfig_templates_unknown(t *testing.T) {
+	configFile := writeTestConfig(t, map[string]string{
+		"main.yaml": `
+constellix:
+  geoproximity:
+    - geo.yaml
+`,
+		"geo.yaml": `
+- name: amsterdam
+  extends: europe
+`,
+	})
+	_, err := getConfig(configFile)
+	expected := "geo.yaml:2: template \"europe\" is not defined"
+	if err == nil || !strings.HasSuffix(err.Error(), expected) {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+}
+
+func TestGetConfig_templates_cycle(t *testing.T) {
+	configFile := writeTestConfig(t, map[string]string{
+		"main.yaml": `
+constellix:
+  templates:
+    a:
+      extends: b
+    b:
+      extends: a
+  geoproximity:
+    - geo.yaml
+`,
+		"geo.yaml": `
+- name: amsterdam
+  extends: a
+`,
+	})
+	_, err := getConfig(configFile)
+	expected := "geo.yaml:2: template \"a\" extends itself"
+	if err == nil || !strings.HasSuffix(err.Error(), expected) {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+}
+
+func TestReadConfigs_patterns(t *testing.T) {
+	configFile := writeTestConfig(t, map[string]string{
+		"main.yaml":                  "",
+		"records/b.yaml":             "b",
+		"records/a.yml":              "a",
+		"records/nested/c.yaml":      "c",
+		"records/nested/notes.txt":   "txt",
+		"records/legacy/d.yaml":      "d",
+		"checks/eu/http.yaml":        "eu",
+		"checks/us/http.yaml":        "us",
+		"checks/us/nested/http.yaml": "us-nested",
+	})
+	baseDir := filepath.Dir(configFile)
+
+	testCases := []struct {
+		entries  []string
+		expected []string
+	}{
+		{[]string{"records"}, []string{"records/a.yml", "records/b.yaml", "records/legacy/d.yaml", "records/nested/c.yaml"}},
+		{[]string{"records", "!records/legacy"}, []string{"records/a.yml", "records/b.yaml", "records/nested/c.yaml"}},
+		{[]string{"checks/**/http.yaml"}, []string{"checks/eu/http.yaml", "checks/us/http.yaml", "checks/us/nested/http.yaml"}},
+		{[]string{"checks/us/http.yaml", "checks/*/http.yaml"}, []string{"checks/us/http.yaml", "checks/eu/http.yaml"}},
+		{[]string{"**/*.yaml", "!**/nested"}, []string{"checks/eu/http.yaml", "checks/us/http.yaml", "main.yaml", "records/b.yaml", "records/legacy/d.yaml"}},
+	}
+	for _, tc := range testCases {
+		files, err := readConfigs(tc.entries, baseDir)
+		if err != nil {
+			t.Errorf("%v: unexpected error: %s", tc.entries, err)
+			continue
+		}
+		var got []string
+		for _, file := range files {
+			rel, _ := filepath.Rel(baseDir, file.path)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		if strings.Join(got, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("%v: expected %v, got %v", tc.entries, tc.expected, got)
+		}
+	}
+}
+
+func TestReadConfigs_no_match(t *testing.T) {
+	configFile := writeTestConfig(t, map[string]string{
+		"main.yaml":      "",
+		"records/a.yaml": "a",
+		"empty/a.txt":    "a",
+	})
+	baseDir := filepath.Dir(configFile)
+
+	testCases := []struct {
+		entries  []string
+		expected string
+	}{
+		{[]string{"record.yaml"}, "no such file or directory"},
+		{[]string{"recrods/*.yaml"}, "doesn't match any files"},
+		{[]string{"records/**/*.yml"}, "doesn't match any files"},
+		{[]string{"empty"}, "doesn't contain any yaml files"},
+		{[]string{"records", "!records/b.yaml"}, "doesn't match any files"},
+	}
+	for _, tc := range testCases {
+		_, err := readConfigs(tc.entries, baseDir)
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("%v: expected error containing %q, got %v", tc.entries, tc.expected, err)
+		}
+	}
+}
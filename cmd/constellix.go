@@ -17,6 +17,7 @@ const ActionDelete ResourceAction = "delete"
 const ActionUpate ResourceAction = "update"
 const ActionOK ResourceAction = "ok"
 const ActionError ResourceAction = "error"
+const ActionUnmanaged ResourceAction = "unmanaged"
 
 var sonarRESTAPIBaseURL string = "https://api.sonar.constellix.com/rest/api"
 var dnsRESTAPIBaseURL string = "https://api.dns.constellix.com/v4"
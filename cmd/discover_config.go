@@ -0,0 +1,636 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Main configuration file of discovered configuration
+const discoveredMainConfigFile = "main.yaml"
+
+// discoveredDNSRecord is a record in the format of configuration files. Fields
+// with default values are omitted, referenced resources are defined by names
+type discoveredDNSRecord struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+	TTL  int    `yaml:"ttl"`
+	Mode string `yaml:"mode"`
+	// Region is a part of the record identifier, it's always defined
+	Region       string        `yaml:"region"`
+	IPFilter     interface{}   `yaml:"ipfilter,omitempty"`
+	IPFilterDrop bool          `yaml:"ipfilterDrop,omitempty"`
+	GeoFailover  bool          `yaml:"geoFailover,omitempty"`
+	GeoProximity interface{}   `yaml:"geoproximity,omitempty"`
+	Enabled      *bool         `yaml:"enabled,omitempty"`
+	Notes        string        `yaml:"notes,omitempty"`
+	Contacts     []interface{} `yaml:"contacts,omitempty"`
+	SkipLookup   bool          `yaml:"skipLookup,omitempty"`
+	Value        interface{}   `yaml:"value"`
+	// Values of other modes are kept as is
+	LastValues interface{} `yaml:"lastValues,omitempty"`
+}
+
+// discoveredDNSDomain holds settings of the domain which can be configured,
+// read-only fields (status, nameservers, timestamps) are dropped
+type discoveredDNSDomain struct {
+	Name             string        `yaml:"name"`
+	Note             string        `yaml:"note,omitempty"`
+	GeoIPEnabled     bool          `yaml:"geoip"`
+	GTDEnabled       bool          `yaml:"gtd"`
+	Tags             []string      `yaml:"tags,omitempty"`
+	Template         int           `yaml:"template,omitempty"`
+	VanityNameserver interface{}   `yaml:"vanityNameserver,omitempty"`
+	Contacts         []int         `yaml:"contacts,omitempty"`
+	SOA              *DNSDomainSOA `yaml:"soa,omitempty"`
+}
+
+func toDiscoveredDNSDomains(domains []*DNSDomain) []*discoveredDNSDomain {
+	discovered := make([]*discoveredDNSDomain, len(domains))
+	for i, d := range domains {
+		discovered[i] = &discoveredDNSDomain{
+			Name:             d.Name,
+			Note:             d.Note,
+			GeoIPEnabled:     d.GeoIPEnabled,
+			GTDEnabled:       d.GTDEnabled,
+			Tags:             d.Tags,
+			Template:         d.Template,
+			VanityNameserver: d.VanityNameserver,
+			Contacts:         d.Contacts,
+			SOA:              d.SOA,
+		}
+	}
+	return discovered
+}
+
+type discoveredFailoverValue struct {
+	Mode    string      `yaml:"mode"`
+	Enabled bool        `yaml:"enabled"`
+	Values  interface{} `yaml:"values"`
+}
+
+type discoveredFailoverItemValue struct {
+	Enabled      bool        `yaml:"enabled"`
+	Order        int         `yaml:"order"`
+	SonarCheckID interface{} `yaml:"sonarCheckId,omitempty"`
+	Value        string      `yaml:"value"`
+}
+
+type discoveredMXFailoverItemValue struct {
+	Enabled      bool        `yaml:"enabled"`
+	Order        int         `yaml:"order"`
+	SonarCheckID interface{} `yaml:"sonarCheckId,omitempty"`
+	Server       string      `yaml:"server"`
+	Priority     int         `yaml:"priority"`
+}
+
+// discoveryReferences rewrites IDs of referenced resources into references by
+// name. Resources are retrieved on first use. An ID is kept if the name is
+// ambiguous or the reference would change the resolved value
+type discoveryReferences struct {
+	httpChecks     []*SonarHTTPCheck
+	geoProximities []*GeoProximity
+	pools          []*Pool
+	ipFilters      []*IPFilter
+	contacts       []*ContactList
+}
+
+// sonarCheck returns the reference to the Sonar HTTP check. The host of the
+// check replaces the value when the reference is resolved, so the reference is
+// used only if the host is the same as the value. Empty value means the host
+// is not used
+func (r *discoveryReferences) sonarCheck(id int, value string) (interface{}, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	if r.httpChecks == nil {
+		checks, err := GetSonarHTTPChecks()
+		if err != nil {
+			return nil, err
+		}
+		r.httpChecks = checks
+	}
+	var found *SonarHTTPCheck
+	for _, check := range r.httpChecks {
+		if check.ID == id {
+			found = check
+		}
+	}
+	if found == nil || strings.Contains(found.Name, ":") || (value != "" && value != found.Host) {
+		return id, nil
+	}
+	for _, check := range r.httpChecks {
+		if check.Name == found.Name && check.ID != id {
+			return id, nil
+		}
+	}
+	return "@sonar,http:" + found.Name, nil
+}
+
+func (r *discoveryReferences) geoProximity(id int) (interface{}, error) {
+	if r.geoProximities == nil {
+		proximities, err := GetGeoProximities()
+		if err != nil {
+			return nil, err
+		}
+		r.geoProximities = proximities
+	}
+	names := make(map[int]string)
+	for _, gp := range r.geoProximities {
+		names[gp.ID] = gp.Name
+	}
+	return uniqueReference("@geoproximity:", id, names), nil
+}
+
+func (r *discoveryReferences) pool(id int, recordType string) (interface{}, error) {
+	if r.pools == nil {
+		pools, err := GetPools()
+		if err != nil {
+			return nil, err
+		}
+		r.pools = pools
+	}
+	// Pools are looked up among pools of the record type
+	names := make(map[int]string)
+	for _, pool := range r.pools {
+		if pool.Type == recordType {
+			names[pool.ID] = pool.Name
+		}
+	}
+	return uniqueReference("@pool:", id, names), nil
+}
+
+func (r *discoveryReferences) ipFilter(id int) (interface{}, error) {
+	if r.ipFilters == nil {
+		ipFilters, err := GetIPFilters()
+		if err != nil {
+			return nil, err
+		}
+		r.ipFilters = ipFilters
+	}
+	names := make(map[int]string)
+	for _, ipFilter := range r.ipFilters {
+		names[ipFilter.ID] = ipFilter.Name
+	}
+	return uniqueReference("@ipfilter:", id, names), nil
+}
+
+func (r *discoveryReferences) contact(id int) (interface{}, error) {
+	if r.contacts == nil {
+		contacts, err := GetContactLists()
+		if err != nil {
+			return nil, err
+		}
+		r.contacts = contacts
+	}
+	names := make(map[int]string)
+	for _, contact := range r.contacts {
+		names[contact.ID] = contact.Name
+	}
+	return uniqueReference("@contact:", id, names), nil
+}
+
+// uniqueReference returns the reference by name if the name is unique, the ID
+// otherwise
+func uniqueReference(prefix string, id int, names map[int]string) interface{} {
+	name, ok := names[id]
+	if !ok {
+		return id
+	}
+	for otherID, otherName := range names {
+		if otherName == name && otherID != id {
+			return id
+		}
+	}
+	return prefix + name
+}
+
+// toDiscoveredDNSRecords converts records retrieved from Constellix into the
+// format of configuration files. Unmanaged records are skipped
+func toDiscoveredDNSRecords(records []*DNSRecord, refs *discoveryReferences) ([]*discoveredDNSRecord, error) {
+	discovered := make([]*discoveredDNSRecord, 0)
+	for _, record := range records {
+		if record.IsUnmanaged() {
+			logger.Printf("  skipping unmanaged %s record %q\n", record.Type, record.Name)
+			continue
+		}
+		d := &discoveredDNSRecord{
+			Name:         record.Name,
+			Type:         record.Type,
+			TTL:          record.TTL,
+			Mode:         record.Mode,
+			Region:       record.Region,
+			IPFilterDrop: record.IPFilterDrop,
+			GeoFailover:  record.GeoFailover,
+			Notes:        record.Notes,
+			SkipLookup:   record.SkipLookup,
+			LastValues:   record.LastValues,
+		}
+		var err error
+		if !record.Enabled {
+			d.Enabled = &record.Enabled
+		}
+		if record.IPFilter != nil {
+			d.IPFilter, err = refs.ipFilter(toInt(record.IPFilter))
+			if err != nil {
+				return nil, err
+			}
+		}
+		if record.GeoProximity != nil {
+			d.GeoProximity, err = refs.geoProximity(toInt(record.GeoProximity))
+			if err != nil {
+				return nil, err
+			}
+		}
+		if contacts, ok := record.Contacts.([]int); ok {
+			for _, contactID := range contacts {
+				contact, err := refs.contact(contactID)
+				if err != nil {
+					return nil, err
+				}
+				d.Contacts = append(d.Contacts, contact)
+			}
+		}
+		d.Value, err = toDiscoveredDNSRecordValue(record, refs)
+		if err != nil {
+			return nil, err
+		}
+		discovered = append(discovered, d)
+	}
+	return discovered, nil
+}
+
+// toDiscoveredDNSRecordValue rewrites Sonar checks and pools of the value into
+// references
+func toDiscoveredDNSRecordValue(record *DNSRecord, refs *discoveryReferences) (interface{}, error) {
+	// Host of the Sonar check is not used as the value of TXT records
+	checkHost := func(value string) string {
+		if record.Type == "TXT" {
+			return ""
+		}
+		return value
+	}
+	switch v := record.Value.(type) {
+	case *DNSFailoverValue:
+		values, err := toDiscoveredFailoverItems(v.Values, refs, checkHost)
+		if err != nil {
+			return nil, err
+		}
+		return &discoveredFailoverValue{Mode: v.Mode, Enabled: v.Enabled, Values: values}, nil
+	case []*DNSFailoverItemValue:
+		return toDiscoveredFailoverItems(v, refs, checkHost)
+	case *DNSMXFailoverValue:
+		values, err := toDiscoveredMXFailoverItems(v.Values, refs)
+		if err != nil {
+			return nil, err
+		}
+		return &discoveredFailoverValue{Mode: v.Mode, Enabled: v.Enabled, Values: values}, nil
+	case []*DNSMXFailoverItemValue:
+		return toDiscoveredMXFailoverItems(v, refs)
+	case []int:
+		// Pools mode
+		pools := make([]interface{}, len(v))
+		for i, poolID := range v {
+			pool, err := refs.pool(poolID, record.Type)
+			if err != nil {
+				return nil, err
+			}
+			pools[i] = pool
+		}
+		return pools, nil
+	}
+	return record.Value, nil
+}
+
+func toDiscoveredFailoverItems(
+	items []*DNSFailoverItemValue, refs *discoveryReferences, checkHost func(string) string,
+) ([]*discoveredFailoverItemValue, error) {
+	values := make([]*discoveredFailoverItemValue, len(items))
+	for i, item := range items {
+		sonarCheckID, err := refs.sonarCheck(item.SonarCheckID, checkHost(item.Value))
+		if err != nil {
+			return nil, err
+		}
+		values[i] = &discoveredFailoverItemValue{
+			Enabled: item.Enabled, Order: item.Order, SonarCheckID: sonarCheckID, Value: item.Value,
+		}
+	}
+	return values, nil
+}
+
+func toDiscoveredMXFailoverItems(items []*DNSMXFailoverItemValue, refs *discoveryReferences) ([]*discoveredMXFailoverItemValue, error) {
+	values := make([]*discoveredMXFailoverItemValue, len(items))
+	for i, item := range items {
+		sonarCheckID, err := refs.sonarCheck(item.SonarCheckID, item.Server)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = &discoveredMXFailoverItemValue{
+			Enabled: item.Enabled, Order: item.Order, SonarCheckID: sonarCheckID, Server: item.Server, Priority: item.Priority,
+		}
+	}
+	return values, nil
+}
+
+// configLayout is a tree of configuration files: the main configuration and
+// resource files referenced by it
+type configLayout struct {
+	main MainConfig
+	// Content of resource files by paths relative to the main configuration
+	files map[string]interface{}
+}
+
+func newConfigLayout() *configLayout {
+	return &configLayout{files: make(map[string]interface{})}
+}
+
+// addSonarHTTPChecks adds the file with Sonar HTTP checks
+func (l *configLayout) addSonarHTTPChecks(checks []*SonarHTTPCheck) {
+	if len(checks) == 0 {
+		return
+	}
+	path := filepath.Join("sonar", "http_checks.yaml")
+	l.main.Constellix.Sonar.HTTPChecksConfigFiles = []string{path}
+	l.files[path] = checks
+}
+
+// addSonarTCPChecks adds the file with Sonar TCP checks
+func (l *configLayout) addSonarTCPChecks(checks []*SonarTCPCheck) {
+	if len(checks) == 0 {
+		return
+	}
+	path := filepath.Join("sonar", "tcp_checks.yaml")
+	l.main.Constellix.Sonar.TCPChecksConfigFiles = []string{path}
+	l.files[path] = checks
+}
+
+// addGeoProximities adds the file with geoproximities
+func (l *configLayout) addGeoProximities(proximities []*GeoProximity) {
+	if len(proximities) == 0 {
+		return
+	}
+	path := "geoproximity.yaml"
+	l.main.Constellix.GeoProximityConfigFiles = []string{path}
+	l.files[path] = proximities
+}
+
+// addDomains adds the file with settings of domains
+func (l *configLayout) addDomains(domains []*discoveredDNSDomain) {
+	if len(domains) == 0 {
+		return
+	}
+	path := "domains.yaml"
+	l.main.Constellix.DomainConfigFiles = []string{path}
+	l.files[path] = domains
+}
+
+// addDNSRecords adds the file with records of the domain
+func (l *configLayout) addDNSRecords(domainName string, records []*discoveredDNSRecord) {
+	path := filepath.Join("dns", domainName+".yaml")
+	if l.main.Constellix.DNS == nil {
+		l.main.Constellix.DNS = make(map[string][]string)
+	}
+	l.main.Constellix.DNS[domainName] = []string{path}
+	l.files[path] = records
+}
+
+// write saves the configuration tree into the directory, it returns the path
+// of the main configuration file
+func (l *configLayout) write(outputDir string) (string, error) {
+	paths := make([]string, 0, len(l.files))
+	for path := range l.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		node, err := toConfigNode(l.files[path])
+		if err != nil {
+			return "", err
+		}
+		err = writeConfigFile(filepath.Join(outputDir, path), node)
+		if err != nil {
+			return "", err
+		}
+	}
+	mainFile := filepath.Join(outputDir, discoveredMainConfigFile)
+	err := writeConfigFile(mainFile, &l.main)
+	if err != nil {
+		return "", err
+	}
+	logger.Printf("Configuration saved to %s\n", mainFile)
+	return mainFile, nil
+}
+
+// toConfigNode encodes resources of the file. Nil fields of active resources
+// are omitted: they are decoded as empty values and would differ from active
+// resources
+func toConfigNode(content interface{}) (*yaml.Node, error) {
+	v := reflect.ValueOf(content)
+	if v.Kind() != reflect.Slice {
+		node := &yaml.Node{}
+		return node, node.Encode(content)
+	}
+	list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for i := 0; i < v.Len(); i++ {
+		item := &yaml.Node{}
+		err := item.Encode(v.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		nilFields := nilYAMLFields(reflect.Indirect(v.Index(i)))
+		if item.Kind == yaml.MappingNode && len(nilFields) > 0 {
+			var fields []*yaml.Node
+			for j := 0; j < len(item.Content); j += 2 {
+				if !slices.Contains(nilFields, item.Content[j].Value) {
+					fields = append(fields, item.Content[j], item.Content[j+1])
+				}
+			}
+			item.Content = fields
+		}
+		list.Content = append(list.Content, item)
+	}
+	return list, nil
+}
+
+// nilYAMLFields returns yaml names of struct fields with nil values
+func nilYAMLFields(v reflect.Value) []string {
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	var fields []string
+	for i := 0; i < v.NumField(); i++ {
+		switch v.Field(i).Kind() {
+		case reflect.Slice, reflect.Map, reflect.Ptr, reflect.Interface:
+			if !v.Field(i).IsNil() {
+				continue
+			}
+			field := v.Type().Field(i)
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+func writeConfigFile(path string, content interface{}) error {
+	dataBytes, err := yaml.Marshal(content)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, dataBytes, 0644)
+}
+
+// discoveredResources holds active resources which were written as
+// configuration
+type discoveredResources struct {
+	SonarHTTPChecks []*SonarHTTPCheck
+	SonarTCPChecks  []*SonarTCPCheck
+	GeoProximities  []*GeoProximity
+	Domains         []*DNSDomain
+	DNS             map[string][]*DNSRecord
+}
+
+// discoverAccountConfig writes all resources of the account as configuration
+// tree and verifies that it matches active resources
+func discoverAccountConfig(outputDir string) error {
+	var active discoveredResources
+	var err error
+	layout := newConfigLayout()
+
+	active.SonarHTTPChecks, err = GetSonarHTTPChecks()
+	if err != nil {
+		return err
+	}
+	logger.Printf("Found %d Sonar HTTP checks\n", len(active.SonarHTTPChecks))
+	layout.addSonarHTTPChecks(active.SonarHTTPChecks)
+
+	active.SonarTCPChecks, err = GetSonarTCPChecks()
+	if err != nil {
+		return err
+	}
+	logger.Printf("Found %d Sonar TCP checks\n", len(active.SonarTCPChecks))
+	layout.addSonarTCPChecks(active.SonarTCPChecks)
+
+	active.GeoProximities, err = GetGeoProximities()
+	if err != nil {
+		return err
+	}
+	logger.Printf("Found %d GeoProximities\n", len(active.GeoProximities))
+	layout.addGeoProximities(active.GeoProximities)
+
+	active.Domains, err = GetDNSDomains()
+	if err != nil {
+		return err
+	}
+	logger.Printf("Found %d domains\n", len(active.Domains))
+	layout.addDomains(toDiscoveredDNSDomains(active.Domains))
+
+	refs := &discoveryReferences{httpChecks: active.SonarHTTPChecks, geoProximities: active.GeoProximities}
+	active.DNS = make(map[string][]*DNSRecord)
+	for _, domain := range active.Domains {
+		records, err := GetDNSRecords(domain.ID)
+		if err != nil {
+			return err
+		}
+		logger.Printf("Found %d DNS records for %s\n", len(records), domain.Name)
+		discovered, err := toDiscoveredDNSRecords(records, refs)
+		if err != nil {
+			return err
+		}
+		layout.addDNSRecords(domain.Name, discovered)
+		active.DNS[domain.Name] = records
+	}
+
+	mainFile, err := layout.write(outputDir)
+	if err != nil {
+		return err
+	}
+	return verifyDiscoveredConfig(mainFile, &active)
+}
+
+// verifyDiscoveredConfig loads the written configuration and checks that
+// syncing it would not change anything
+func verifyDiscoveredConfig(mainFile string, active *discoveredResources) error {
+	config, err := getConfig(mainFile)
+	if err != nil {
+		return fmt.Errorf("unable to load discovered configuration: %s", err)
+	}
+	var problems []string
+	add := func(label string, expected, active []ResourceMatcher) error {
+		found, err := compareDiscoveredResources(label, expected, active)
+		problems = append(problems, found...)
+		return err
+	}
+	err = add("Sonar HTTP checks", toResourceMatcher(config.SonarHTTPChecks), toResourceMatcher(active.SonarHTTPChecks))
+	if err != nil {
+		return err
+	}
+	err = add("Sonar TCP checks", toResourceMatcher(config.SonarTCPChecks), toResourceMatcher(active.SonarTCPChecks))
+	if err != nil {
+		return err
+	}
+	err = add("Geoproximities", toResourceMatcher(config.GeoProximities), toResourceMatcher(active.GeoProximities))
+	if err != nil {
+		return err
+	}
+	err = add("Domains", toResourceMatcher(config.Domains), toResourceMatcher(active.Domains))
+	if err != nil {
+		return err
+	}
+	for domainName, records := range active.DNS {
+		var managed []*DNSRecord
+		for _, record := range records {
+			if !record.IsUnmanaged() {
+				managed = append(managed, record)
+			}
+		}
+		err = add(domainName, toResourceMatcher(config.DNS[domainName]), toResourceMatcher(managed))
+		if err != nil {
+			return err
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("discovered configuration doesn't match active resources:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// compareDiscoveredResources returns actions which syncing of the resources
+// would perform
+func compareDiscoveredResources(label string, expectedResources, activeResources []ResourceMatcher) ([]string, error) {
+	var problems []string
+	for _, r := range expectedResources {
+		expected := r.(IExpectedResource)
+		var activeResource IActiveResource
+		if matched := getMatchingResource(expected, activeResources); matched != nil {
+			activeResource = matched.(IActiveResource)
+		}
+		action, _, err := Compare(expected, activeResource)
+		if err != nil {
+			return nil, err
+		}
+		if action != ActionOK {
+			problems = append(problems, fmt.Sprintf("%s: %s %s", label, action, expected.GetResourceID()))
+		}
+	}
+	for _, a := range activeResources {
+		if getMatchingResource(a, expectedResources) == nil {
+			problems = append(problems, fmt.Sprintf("%s: %s %s", label, ActionDelete, a.GetResourceID()))
+		}
+	}
+	return problems, nil
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func mockDiscoveryReferences(t *testing.T) {
+	t.Helper()
+	originalGetGeoProximities := GetGeoProximities
+	originalGetPools := GetPools
+	originalGetContactLists := GetContactLists
+	originalCachedSonarHTTPChecks := cachedSonarHTTPChecks
+	t.Cleanup(func() {
+		GetGeoProximities = originalGetGeoProximities
+		GetPools = originalGetPools
+		GetContactLists = originalGetContactLists
+		cachedSonarHTTPChecks = originalCachedSonarHTTPChecks
+	})
+	GetGeoProximities = func() ([]*GeoProximity, error) {
+		return []*GeoProximity{{ID: 5, Name: "amsterdam"}}, nil
+	}
+	GetPools = func() ([]*Pool, error) {
+		return []*Pool{{ID: 3, Name: "web-eu", Type: "A"}, {ID: 4, Name: "web-eu", Type: "AAAA"}}, nil
+	}
+	GetContactLists = func() ([]*ContactList, error) {
+		return []*ContactList{{ID: 7, Name: "ops"}}, nil
+	}
+	cachedSonarHTTPChecks = []*SonarHTTPCheck{
+		{ID: 101, Name: "web-1", Host: "192.0.2.1"},
+		{ID: 102, Name: "web-2", Host: "web-2.example.net"},
+	}
+}
+
+const discoveryTestRecords = `[
+{"id":1,"name":"","type":"A","ttl":300,"mode":"standard","region":"default","ipfilter":null,"ipfilterDrop":false,"geoFailover":false,"geoproximity":null,"enabled":true,"value":[{"value":"192.0.2.1","enabled":true}],"notes":"","skipLookup":false,"contacts":[{"id":7,"name":"ops"}]},
+{"id":2,"name":"www","type":"A","ttl":60,"mode":"failover","region":"default","enabled":true,"value":{"enabled":true,"mode":"normal","values":[{"value":"192.0.2.1","order":1,"sonarCheckId":101,"enabled":true},{"value":"192.0.2.2","order":2,"sonarCheckId":102,"enabled":true},{"value":"192.0.2.3","order":3,"sonarCheckId":null,"enabled":true}]}},
+{"id":3,"name":"web","type":"A","ttl":60,"mode":"pools","region":"default","enabled":true,"value":[3],"lastValues":{"standard":[{"value":"192.0.2.9","enabled":true}],"pools":[3]}},
+{"id":4,"name":"cdn","type":"CNAME","ttl":600,"mode":"standard","region":"europe","geoproximity":{"id":5,"name":"amsterdam"},"enabled":false,"value":[{"value":"eu.cdn.example.net.","enabled":true}]},
+{"id":5,"name":"key","type":"SSHFP","ttl":3600,"mode":"standard","region":"default","enabled":true,"value":[{"algorithm":1}]}
+]`
+
+func TestDiscoverDNSRecordsAsConfig(t *testing.T) {
+	mockDiscoveryReferences(t)
+	var records []*DNSRecord
+	err := json.Unmarshal([]byte(discoveryTestRecords), &records)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	discovered, err := toDiscoveredDNSRecords(records, &discoveryReferences{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout := newConfigLayout()
+	layout.addDNSRecords("example.com", discovered)
+	outputDir := t.TempDir()
+	mainFile, err := layout.write(outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mainData, err := os.ReadFile(mainFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedMain := `constellix:
+    dns:
+        example.com:
+            - dns/example.com.yaml
+`
+	if string(mainData) != expectedMain {
+		t.Errorf("expected main config:\n%s\ngot:\n%s", expectedMain, mainData)
+	}
+	recordsData, err := os.ReadFile(filepath.Join(outputDir, "dns", "example.com.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"contacts:\n    - '@contact:ops'",
+		"sonarCheckId: '@sonar,http:web-1'",
+		// Host of the check differs from the value, the ID is kept
+		"sonarCheckId: 102",
+		"- '@pool:web-eu'",
+		"geoproximity: '@geoproximity:amsterdam'",
+		"enabled: false\n  value:",
+		"lastValues:\n    pools:\n        - 3\n    standard:\n        - enabled: true\n          value: 192.0.2.9",
+	} {
+		if !strings.Contains(string(recordsData), expected) {
+			t.Errorf("expected %q in records:\n%s", expected, recordsData)
+		}
+	}
+	for _, unexpected := range []string{"SSHFP", "notes", "ipfilter", "sonarCheckId: 0"} {
+		if strings.Contains(string(recordsData), unexpected) {
+			t.Errorf("unexpected %q in records:\n%s", unexpected, recordsData)
+		}
+	}
+
+	active := map[string][]*DNSRecord{"example.com": records}
+	err = verifyDiscoveredConfig(mainFile, &discoveredResources{DNS: active})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Changed records are reported
+	records[0].TTL = 600
+	records = append(records, &DNSRecord{ID: 6, Name: "new", Type: "A", Region: "default"})
+	err = verifyDiscoveredConfig(mainFile, &discoveredResources{DNS: map[string][]*DNSRecord{"example.com": records}})
+	expectedErr := "discovered configuration doesn't match active resources:\n" +
+		"  example.com: delete A \"new\" (default, 0)\n" +
+		"  example.com: update A \"\" (default, 0)"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("expected error %q, got %v", expectedErr, err)
+	}
+}
+
+func TestDiscoverAccountConfig(t *testing.T) {
+	mockDiscoveryReferences(t)
+	var active discoveredResources
+	err := json.Unmarshal([]byte(`{
+"SonarHTTPChecks": [{"id":101,"name":"web-1","host":"192.0.2.1","ipVersion":"IPV4","port":443,"protocolType":"HTTPS","interval":"ONEMINUTE","checkSites":[1,2],"userId":300,"notificationGroups":[]}],
+"SonarTCPChecks": [{"id":201,"name":"smtp","host":"192.0.2.25","ipVersion":"IPV4","port":25,"interval":"FIVEMINUTES","checkSites":[1]}],
+"GeoProximities": [{"id":5,"name":"amsterdam","country":"NL","city":0,"longitude":4.89,"latitude":52.37}],
+"Domains": [{"id":10,"name":"example.com","status":"ACTIVE","gtd":true,"nameservers":["ns11.constellix.com"],"tags":[],"vanityNameserver":{"id":3,"name":"vanity"},"contacts":[7],
+  "soa":{"primaryNameserver":"ns11.constellix.com.","email":"dns.constellix.com.","ttl":86400,"refresh":86400,"retry":7200,"expire":3600000,"negativeCache":180},
+  "createdAt":"2024-01-01T00:00:00+00:00"}]
+}`), &active)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []*DNSRecord
+	err = json.Unmarshal([]byte(discoveryTestRecords), &records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	active.DNS = map[string][]*DNSRecord{"example.com": records}
+
+	layout := newConfigLayout()
+	layout.addSonarHTTPChecks(active.SonarHTTPChecks)
+	layout.addSonarTCPChecks(active.SonarTCPChecks)
+	layout.addGeoProximities(active.GeoProximities)
+	layout.addDomains(toDiscoveredDNSDomains(active.Domains))
+	discovered, err := toDiscoveredDNSRecords(records, &discoveryReferences{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout.addDNSRecords("example.com", discovered)
+	outputDir := t.TempDir()
+	mainFile, err := layout.write(outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mainData, err := os.ReadFile(mainFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedMain := `constellix:
+    sonar:
+        http_checks:
+            - sonar/http_checks.yaml
+        tcp_checks:
+            - sonar/tcp_checks.yaml
+    geoproximity:
+        - geoproximity.yaml
+    domains:
+        - domains.yaml
+    dns:
+        example.com:
+            - dns/example.com.yaml
+`
+	if string(mainData) != expectedMain {
+		t.Errorf("expected main config:\n%s\ngot:\n%s", expectedMain, mainData)
+	}
+	domainsData, err := os.ReadFile(filepath.Join(outputDir, "domains.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, unexpected := range []string{"status", "nameservers", "createdat"} {
+		if strings.Contains(string(domainsData), unexpected) {
+			t.Errorf("unexpected %q in domains:\n%s", unexpected, domainsData)
+		}
+	}
+
+	err = verifyDiscoveredConfig(mainFile, &active)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Resources created after the discovery are reported
+	active.GeoProximities = append(active.GeoProximities, &GeoProximity{ID: 6, Name: "tokyo"})
+	active.Domains[0].GTDEnabled = false
+	err = verifyDiscoveredConfig(mainFile, &active)
+	expectedErr := "discovered configuration doesn't match active resources:\n" +
+		"  Domains: update example.com\n" +
+		"  Geoproximities: delete tokyo"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("expected error %q, got %v", expectedErr, err)
+	}
+}
@@ -1,26 +1,259 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"strings"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	yaml "gopkg.in/yaml.v3"
 )
 
-// SOA is ignored for now
 type DNSDomain struct {
-	ID               int         `json:"id"`
-	Name             string      `json:"name"`
-	Note             string      `json:"note"`
-	Status           string      `json:"status"`
-	GeoIPEnabled     bool        `json:"geoip"`
-	GTDEnabled       bool        `json:"gtd"`
-	Nameservers      []string    `json:"nameservers"`
-	Tags             []string    `json:"tags"`
-	Template         int         `json:"template"`
-	VanityNameserver interface{} `json:"vanityNameserver"`
-	Contacts         []int       `json:"contacts"`
-	CreatedAt        string      `json:"createdAt"`
-	UpdatedAt        string      `json:"updatedAt"`
+	ID           int      `json:"id"`
+	Name         string   `json:"name" yaml:"name"`
+	Note         string   `json:"note" yaml:"note"`
+	Status       string   `json:"status"`
+	GeoIPEnabled bool     `json:"geoip" yaml:"geoip"`
+	GTDEnabled   bool     `json:"gtd" yaml:"gtd"`
+	Nameservers  []string `json:"nameservers"`
+	Tags         []string `json:"tags" yaml:"tags"`
+	Template     int      `json:"template" yaml:"template"`
+	// In Constellix API, GET requests return an object, but POST and PUT
+	// requests expect an integer
+	VanityNameserver interface{}   `json:"vanityNameserver" yaml:"vanityNameserver"`
+	Contacts         []int         `json:"contacts" yaml:"contacts"`
+	SOA              *DNSDomainSOA `json:"soa" yaml:"soa"`
+	CreatedAt        string        `json:"createdAt"`
+	UpdatedAt        string        `json:"updatedAt"`
+}
+
+// DNSDomainSOA represents SOA record of the domain. Serial is managed by
+// Constellix
+type DNSDomainSOA struct {
+	PrimaryNameserver string `json:"primaryNameserver" yaml:"primaryNameserver"`
+	Email             string `json:"email" yaml:"email"`
+	TTL               int    `json:"ttl" yaml:"ttl"`
+	Refresh           int    `json:"refresh" yaml:"refresh"`
+	Retry             int    `json:"retry" yaml:"retry"`
+	Expire            int    `json:"expire" yaml:"expire"`
+	NegativeCache     int    `json:"negativeCache" yaml:"negativeCache"`
+}
+
+// SOA is compared and updated as a whole, all fields must be defined
+var dnsDomainSOAFields = []string{"primaryNameserver", "email", "ttl", "refresh", "retry", "expire", "negativeCache"}
+
+type aliasDNSDomain DNSDomain
+
+func (ac *DNSDomain) UnmarshalJSON(b []byte) error {
+	var alias aliasDNSDomain
+	err := json.Unmarshal(b, &alias)
+	if err != nil {
+		return err
+	}
+	s := DNSDomain(alias)
+	if elMap, ok := s.VanityNameserver.(map[string]interface{}); ok {
+		s.VanityNameserver = toInt(elMap["id"])
+	}
+	*ac = s
+	return nil
+}
+
+func (ac *DNSDomain) GetResource() interface{} {
+	return ac
+}
+
+func (ac *DNSDomain) GetResourceID() string {
+	return ac.Name
+}
+
+func (ac *DNSDomain) GetConstellixID() int {
+	return ac.ID
+}
+
+// SyncResourceDelete never removes the domain with all its records, it must be
+// done manually
+func (ac *DNSDomain) SyncResourceDelete(constellixID int) error {
+	return fmt.Errorf("unable to delete domain %s: removing of domains is not supported", ac.Name)
+}
+
+type ExpectedDNSDomain struct {
+	// Mapping of defined fields from parsed data to struct Field Names
+	definedFieldsMap map[string]string
+	// List of immutable fields which can't be updated via API
+	immutableFields []string
+	// List of mandatory fields which must be defined, used for validation
+	mandatoryFields []string
+	// List of SOA fields which are defined, used for validation
+	definedSOAFields []string
+	// Location of the resource in configuration files
+	source string
+	DNSDomain
+}
+
+// UnmarshalYAML unmarshals the mesage and stores original fields
+func (ex *ExpectedDNSDomain) UnmarshalYAML(value *yaml.Node) error {
+	ex.immutableFields = []string{"name"}
+	ex.mandatoryFields = []string{"name"}
+
+	// Unmarshall data into DNSDomain struct
+	var s DNSDomain
+	err := value.Decode(&s)
+	if err != nil {
+		return err
+	}
+	if s.VanityNameserver != nil {
+		switch s.VanityNameserver.(type) {
+		case int, float64:
+			s.VanityNameserver = toInt(s.VanityNameserver)
+		default:
+			return fmt.Errorf("unable to parse value for vanityNameserver, expected an integer")
+		}
+	}
+	ex.DNSDomain = s
+
+	// Save specified fields
+	dm := make(map[string]interface{})
+	err = value.Decode(&dm)
+	if err != nil {
+		return err
+	}
+
+	definedFields := make([]string, len(dm))
+	i := 0
+	for k := range dm {
+		definedFields[i] = k
+		i++
+	}
+	ex.definedFieldsMap = getFieldNamesMap(&ex.DNSDomain, "yaml", definedFields...)
+	if soa, ok := dm["soa"].(map[string]interface{}); ok {
+		ex.definedSOAFields = maps.Keys(soa)
+	}
+	return nil
+}
+
+// Validate performs simple validation of user provided data
+func (ex *ExpectedDNSDomain) Validate() error {
+	// Validate that all mandatory fields are present
+	for _, f := range ex.mandatoryFields {
+		if !slices.Contains(maps.Keys(ex.definedFieldsMap), f) {
+			return fmt.Errorf("%s: mandatory field %q is not defined", ex.Name, f)
+		}
+	}
+	if ex.SOA != nil && ex.SOA.PrimaryNameserver != "" && !hostnameRe.MatchString(ex.SOA.PrimaryNameserver) {
+		return fmt.Errorf("%s: invalid SOA primary nameserver %q, expected a hostname", ex.Name, ex.SOA.PrimaryNameserver)
+	}
+	if ex.SOA != nil {
+		var missing []string
+		for _, f := range dnsDomainSOAFields {
+			if !slices.Contains(ex.definedSOAFields, f) {
+				missing = append(missing, f)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("%s: SOA fields %s are not defined, SOA is updated as a whole", ex.Name, strings.Join(missing, ", "))
+		}
+	}
+	return nil
+}
+
+// GetSource returns location of the resource in configuration files
+func (ex *ExpectedDNSDomain) GetSource() string {
+	return ex.source
+}
+
+// SetSource sets location of the resource in configuration files
+func (ex *ExpectedDNSDomain) SetSource(source string) {
+	ex.source = source
+}
+
+// GetDefinedStructFieldNames returns list of defined struct fields from local configuration
+func (ex *ExpectedDNSDomain) GetDefinedStructFieldNames() []string {
+	return maps.Values(ex.definedFieldsMap)
+}
+
+// GetImmutableStructFields returns list of immutable struct fields
+func (ex *ExpectedDNSDomain) GetImmutableStructFields() []string {
+	var imf []string
+	for k, v := range ex.definedFieldsMap {
+		if slices.Contains(ex.immutableFields, k) {
+			imf = append(imf, v)
+		}
+	}
+	return imf
+}
+
+func (ex *ExpectedDNSDomain) GetResource() interface{} {
+	return ex.DNSDomain
+}
+
+func (ex *ExpectedDNSDomain) GetResourceID() string {
+	return ex.Name
+}
+
+func (ex *ExpectedDNSDomain) SyncResourceUpdate(constellixID int) error {
+	logger.Printf("  updating resource %q\n", ex.GetResourceID())
+	endpoint, err := url.JoinPath(dnsRESTAPIBaseURL, "domains", fmt.Sprint(constellixID))
+	if err != nil {
+		return err
+	}
+	// Name of the domain can't be changed
+	payload, err := generatePayload(ex, maps.Keys(ex.definedFieldsMap), []string{"name"})
+	if err != nil {
+		return err
+	}
+	payloadReader := bytes.NewReader(payload)
+	data, err := makev4APIRequest("PUT", endpoint, payloadReader, 200)
+	if err != nil {
+		var details string
+		for _, item := range data {
+			details += string(item)
+		}
+		logger.Println("  unexpected response. Details: " + details)
+		return fmt.Errorf("unable to update DNS domain: %s", err)
+	}
+	return nil
+}
+
+func (ex *ExpectedDNSDomain) SyncResourceCreate() error {
+	logger.Printf("  creating new resource %q\n", ex.GetResourceID())
+	endpoint, err := url.JoinPath(dnsRESTAPIBaseURL, "domains")
+	if err != nil {
+		return err
+	}
+	payload, err := generatePayload(ex, maps.Keys(ex.definedFieldsMap), nil)
+	if err != nil {
+		return err
+	}
+	payloadReader := bytes.NewReader(payload)
+	data, err := makev4APIRequest("POST", endpoint, payloadReader, 202)
+	if err != nil {
+		var details string
+		for _, item := range data {
+			details += string(item)
+		}
+		logger.Println("  unexpected response. Details: " + details)
+		return fmt.Errorf("unable to create DNS domain: %s", err)
+	}
+	return nil
+}
+
+// filterConfiguredDNSDomains returns only domains which are defined in the
+// configuration, other domains are not managed
+func filterConfiguredDNSDomains(active []*DNSDomain, expected []*ExpectedDNSDomain) []*DNSDomain {
+	var filtered []*DNSDomain
+	for _, domain := range active {
+		for _, ex := range expected {
+			if ex.GetResourceID() == domain.GetResourceID() {
+				filtered = append(filtered, domain)
+				break
+			}
+		}
+	}
+	return filtered
 }
 
 // GetDNSDomains returns active DNS domains in Constellix
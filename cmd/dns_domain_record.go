@@ -3,6 +3,7 @@ package cmd
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 
@@ -13,7 +14,6 @@ import (
 
 var dnsRecordResourceIDTemplate = "%s %q (%s, %d)"
 
-// Missing fields: lastValues, skipLookup, contacts
 type DNSRecord struct {
 	ID                   int         `json:"id"`
 	Name                 string      `json:"name" yaml:"name"`
@@ -28,7 +28,15 @@ type DNSRecord struct {
 	Enabled              bool        `json:"enabled" yaml:"enabled"`
 	Value                interface{} `json:"value" yaml:"value"`
 	Notes                string      `json:"notes" yaml:"notes"`
+	Contacts             interface{} `json:"contacts" yaml:"contacts"`
+	SkipLookup           bool        `json:"skipLookup" yaml:"skipLookup"`
+	LastValues           interface{} `json:"lastValues" yaml:"lastValues"`
 	domainIDInConstellix int
+	// Original API response for records of unsupported types. Such records
+	// are unmanaged: they are reported, but never updated
+	raw json.RawMessage
+	// Allows removing of the unmanaged record
+	removable bool
 }
 
 func (ac *DNSRecord) UnmarshalJSON(b []byte) error {
@@ -39,7 +47,12 @@ func (ac *DNSRecord) UnmarshalJSON(b []byte) error {
 	}
 	s := DNSRecord(alias)
 	err = populateDNSRecordValue(&s)
-	if err != nil {
+	if errors.Is(err, errUnsupportedRecordType) {
+		if logLevel > 0 {
+			logger.Printf("  %s, record %q is unmanaged\n", err, s.Name)
+		}
+		s.raw = append(json.RawMessage{}, b...)
+	} else if err != nil {
 		return err
 	}
 	err = populateDNSRecordIPFilterForJSON(&s)
@@ -50,10 +63,47 @@ func (ac *DNSRecord) UnmarshalJSON(b []byte) error {
 	if err != nil {
 		return err
 	}
+	err = populateDNSRecordContactsForJSON(&s)
+	if err != nil {
+		return err
+	}
 	*ac = s
 	return nil
 }
 
+// MarshalYAML exports unmanaged records verbatim, as they are returned by API
+func (ac *DNSRecord) MarshalYAML() (interface{}, error) {
+	if ac.raw == nil {
+		return (*aliasDNSRecord)(ac), nil
+	}
+	var data yaml.Node
+	err := yaml.Unmarshal(ac.raw, &data)
+	if err != nil {
+		return nil, err
+	}
+	resetNodeStyle(&data)
+	return data.Content[0], nil
+}
+
+// resetNodeStyle resets JSON style (flow collections, quoted strings) of the
+// node, so it is rendered as regular YAML
+func resetNodeStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetNodeStyle(child)
+	}
+}
+
+// IsUnmanaged returns true if the type of the record is not supported
+func (ac *DNSRecord) IsUnmanaged() bool {
+	return ac.raw != nil
+}
+
+// IsRemovable returns true if removing of the unmanaged record is allowed
+func (ac *DNSRecord) IsRemovable() bool {
+	return ac.removable
+}
+
 func (ac *DNSRecord) GetResource() interface{} {
 	return ac
 }
@@ -103,6 +153,8 @@ type ExpectedDNSRecord struct {
 	immutableFields []string
 	// List of mandatory fields which must be defined, used for validation
 	mandatoryFields []string
+	// Location of the resource in configuration files
+	source string
 	DNSRecord
 }
 
@@ -118,10 +170,18 @@ func (ex *ExpectedDNSRecord) UnmarshalYAML(value *yaml.Node) error {
 		return err
 	}
 
+	err = populateDNSRecordPoolsForYAML(&s)
+	if err != nil {
+		return err
+	}
 	err = populateDNSRecordValue(&s)
 	if err != nil {
 		return err
 	}
+	err = validateDNSRecordValue(&s)
+	if err != nil {
+		return err
+	}
 	err = populateDNSRecordIPFilterForYAML(&s)
 	if err != nil {
 		return err
@@ -130,6 +190,14 @@ func (ex *ExpectedDNSRecord) UnmarshalYAML(value *yaml.Node) error {
 	if err != nil {
 		return err
 	}
+	err = populateDNSRecordContactsForYAML(&s)
+	if err != nil {
+		return err
+	}
+	err = populateDNSRecordLastValuesForYAML(&s)
+	if err != nil {
+		return err
+	}
 	ex.DNSRecord = s
 
 	// Save specified fields
@@ -149,6 +217,25 @@ func (ex *ExpectedDNSRecord) UnmarshalYAML(value *yaml.Node) error {
 	return nil
 }
 
+// Validate checks that the region of the record is a known GTD region
+func (ex *ExpectedDNSRecord) Validate() error {
+	err := validateDNSRecordRegion(&ex.DNSRecord)
+	if err != nil {
+		return fmt.Errorf("%s: %s", ex.GetResourceID(), err)
+	}
+	return nil
+}
+
+// GetSource returns location of the resource in configuration files
+func (ex *ExpectedDNSRecord) GetSource() string {
+	return ex.source
+}
+
+// SetSource sets location of the resource in configuration files
+func (ex *ExpectedDNSRecord) SetSource(source string) {
+	ex.source = source
+}
+
 // GetDefinedStructFieldNames returns list of defined struct fields from local configuration
 func (ex *ExpectedDNSRecord) GetDefinedStructFieldNames() []string {
 	return maps.Values(ex.definedFieldsMap)
@@ -234,6 +321,30 @@ func (ex *ExpectedDNSRecord) SyncResourceCreate() error {
 	return nil
 }
 
+// filterProtectedDNSRecords removes apex NS records which are not present in the
+// configuration from the list of active records. They are managed by Constellix
+// and removing them breaks the delegation of the domain
+func filterProtectedDNSRecords(active []*DNSRecord, expected []*ExpectedDNSRecord) []*DNSRecord {
+	var filtered []*DNSRecord
+OUTER:
+	for _, record := range active {
+		if record.Type == "NS" && record.Name == "" {
+			for _, ex := range expected {
+				if ex.GetResourceID() == record.GetResourceID() {
+					filtered = append(filtered, record)
+					continue OUTER
+				}
+			}
+			if logLevel > 0 {
+				logger.Printf("  skipping %q, apex NS records are managed by Constellix\n", record.GetResourceID())
+			}
+			continue
+		}
+		filtered = append(filtered, record)
+	}
+	return filtered
+}
+
 // GetDNSRecords retrieves domain's DNS records
 func GetDNSRecords(id int) ([]*DNSRecord, error) {
 	if logLevel > 0 {
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ContactList is a list of emails which are notified about changes of the
+// record
+type ContactList struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// In Constellix API, GET requests return a list of objects, but POST and PATCH
+// requests expect a list of integers. yaml configuration can have integers or
+// references to the contact lists by name.
+
+// populateDNSRecordContactsForJSON populates the Contacts field from the JSON
+// response from the API.
+func populateDNSRecordContactsForJSON(record interface{}) error {
+	s, ok := record.(*DNSRecord)
+	if !ok {
+		return fmt.Errorf("unable to assert record to DNSRecord")
+	}
+	if s.Contacts == nil {
+		return nil
+	}
+	items, ok := s.Contacts.([]interface{})
+	if !ok {
+		return fmt.Errorf("unable to parse value for contacts, expected an array")
+	}
+	contacts := make([]int, 0)
+	for _, item := range items {
+		if elMap, ok := item.(map[string]interface{}); ok {
+			contacts = append(contacts, toInt(elMap["id"]))
+		} else {
+			contacts = append(contacts, toInt(item))
+		}
+	}
+	s.Contacts = contacts
+	return nil
+}
+
+// populateDNSRecordContactsForYAML populates the Contacts field from the
+// local YAML configuration.
+func populateDNSRecordContactsForYAML(record interface{}) error {
+	s, ok := record.(*DNSRecord)
+	if !ok {
+		return fmt.Errorf("unable to assert record to DNSRecord")
+	}
+	if s.Contacts == nil {
+		return nil
+	}
+	items, ok := s.Contacts.([]interface{})
+	if !ok {
+		return fmt.Errorf("unable to parse value for contacts, expected an array")
+	}
+	contacts := make([]int, 0)
+	for _, item := range items {
+		contactID, err := getContactID(item)
+		if err != nil {
+			return err
+		}
+		contacts = append(contacts, contactID)
+	}
+	s.Contacts = contacts
+	return nil
+}
+
+// getContactID returns the ID of the contact list. It supports both an integer
+// and a string `@contact:Name`.
+func getContactID(contact interface{}) (int, error) {
+	switch v := contact.(type) {
+	case string:
+		if !strings.HasPrefix(v, "@contact:") {
+			return 0, fmt.Errorf("invalid contact value. Expected @contact:<name> or int")
+		}
+		name := strings.TrimPrefix(v, "@contact:")
+		name = strings.TrimSpace(name)
+		contactLists, err := GetContactLists()
+		if err != nil {
+			return 0, err
+		}
+		for _, c := range contactLists {
+			if c.Name == name {
+				return c.ID, nil
+			}
+		}
+		return 0, fmt.Errorf("unable to find contact %s", name)
+	case int, float64:
+		return toInt(contact), nil
+	default:
+		return 0, fmt.Errorf("invalid contact value. Expected @contact:<name> or int")
+	}
+}
+
+// GetContactLists returns contact lists
+var GetContactLists = func() ([]*ContactList, error) {
+	if logLevel > 0 {
+		logger.Println("Retrieving contact lists...")
+	}
+	endpoint, err := url.JoinPath(dnsRESTAPIBaseURL, "contactlists")
+	if err != nil {
+		return nil, err
+	}
+	data, err := makev4APIRequest("GET", endpoint, nil, 200)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve contact lists: %s", err)
+	}
+
+	contactLists := make([]*ContactList, 0)
+	for _, item := range data {
+		var tmpContactLists []*ContactList
+		err = json.Unmarshal(item, &tmpContactLists)
+		if err != nil {
+			return nil, err
+		}
+		if len(tmpContactLists) > 0 {
+			contactLists = append(contactLists, tmpContactLists...)
+		}
+	}
+	return contactLists, nil
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPopulateDNSRecordContactsForJSON(t *testing.T) {
+	record := &DNSRecord{
+		Contacts: []interface{}{
+			map[string]interface{}{"id": float64(1), "name": "ops"},
+			float64(2),
+		},
+	}
+	err := populateDNSRecordContactsForJSON(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(record.Contacts, []int{1, 2}) {
+		t.Errorf("expected %v, got %v", []int{1, 2}, record.Contacts)
+	}
+}
+
+func TestPopulateDNSRecordContactsForYAML(t *testing.T) {
+	// Mock the GetContactLists function to control its behavior for testing
+	oldGetContactLists := GetContactLists
+	defer func() { GetContactLists = oldGetContactLists }()
+	GetContactLists = func() ([]*ContactList, error) {
+		return []*ContactList{
+			{ID: 7, Name: "ops"},
+		}, nil
+	}
+
+	record := &DNSRecord{Contacts: []interface{}{"@contact: ops", 3}}
+	err := populateDNSRecordContactsForYAML(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(record.Contacts, []int{7, 3}) {
+		t.Errorf("expected %v, got %v", []int{7, 3}, record.Contacts)
+	}
+
+	record = &DNSRecord{Contacts: []interface{}{"@contact:dev"}}
+	err = populateDNSRecordContactsForYAML(record)
+	if err == nil || err.Error() != "unable to find contact dev" {
+		t.Errorf("expected missing contact error, got %v", err)
+	}
+}
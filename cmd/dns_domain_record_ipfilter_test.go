@@ -18,3 +18,36 @@ func TestPopulateDNSRecordIpfilterForJSON_ValidObject(t *testing.T) {
 		t.Errorf("populateDNSRecordIPFilterForJSON() = %v, want %v", record.IPFilter, 1)
 	}
 }
+
+func TestPopulateDNSRecordIpfilterForYAML_Reference(t *testing.T) {
+	// Mock the GetIPFilters function to control its behavior for testing
+	oldGetIPFilters := GetIPFilters
+	defer func() { GetIPFilters = oldGetIPFilters }()
+	GetIPFilters = func() ([]*IPFilter, error) {
+		return []*IPFilter{
+			{ID: 3, Name: "eu-only"},
+		}, nil
+	}
+
+	tests := []struct {
+		input   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{"@ipfilter:eu-only", 3, false},
+		{"@ipfilter: eu-only ", 3, false},
+		{5, 5, false},
+		{"@ipfilter:us-only", "@ipfilter:us-only", true},
+		{"eu-only", "eu-only", true},
+	}
+	for _, test := range tests {
+		record := &DNSRecord{IPFilter: test.input}
+		err := populateDNSRecordIPFilterForYAML(record)
+		if (err != nil) != test.wantErr {
+			t.Errorf("populateDNSRecordIPFilterForYAML(%v) error = %v, wantErr %v", test.input, err, test.wantErr)
+		}
+		if record.IPFilter != test.want {
+			t.Errorf("populateDNSRecordIPFilterForYAML(%v) = %v, want %v", test.input, record.IPFilter, test.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// getPoolID returns the ID of the pool. It supports both an integer and a
+// string `@pool:Name`, the pool is looked up among pools of the record type
+func getPoolID(pool interface{}, recordType string) (int, error) {
+	switch v := pool.(type) {
+	case string:
+		if !strings.HasPrefix(v, "@pool:") {
+			return 0, fmt.Errorf("invalid pool value. Expected @pool:<name> or int")
+		}
+		name := strings.TrimPrefix(v, "@pool:")
+		name = strings.TrimSpace(name)
+		pools, err := GetPools()
+		if err != nil {
+			return 0, err
+		}
+		for _, p := range pools {
+			if p.Name == name && p.Type == recordType {
+				return p.ID, nil
+			}
+		}
+		return 0, fmt.Errorf("unable to find %s pool %s", recordType, name)
+	case int, float64:
+		return toInt(pool), nil
+	default:
+		return 0, fmt.Errorf("invalid pool value. Expected @pool:<name> or int")
+	}
+}
+
+// populateDNSRecordPoolsForYAML resolves pools of the record in pools mode from
+// the local YAML configuration into IDs
+func populateDNSRecordPoolsForYAML(record *DNSRecord) error {
+	if record.Mode != "pools" {
+		return nil
+	}
+	pools, ok := record.Value.([]interface{})
+	if !ok {
+		return nil
+	}
+	for i, pool := range pools {
+		poolID, err := getPoolID(pool, record.Type)
+		if err != nil {
+			return err
+		}
+		pools[i] = poolID
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestGetPoolID(t *testing.T) {
+	// Mock the GetPools function to control its behavior for testing
+	oldGetPools := GetPools
+	defer func() { GetPools = oldGetPools }()
+	GetPools = func() ([]*Pool, error) {
+		return []*Pool{
+			{ID: 1, Name: "web-eu", Type: "A"},
+			{ID: 2, Name: "web-eu", Type: "AAAA"},
+		}, nil
+	}
+
+	tests := []struct {
+		input      interface{}
+		recordType string
+		want       int
+		wantErr    bool
+	}{
+		{"@pool:web-eu", "A", 1, false},
+		{"@pool: web-eu ", "AAAA", 2, false},
+		{10, "A", 10, false},
+		{"@pool:web-eu", "CNAME", 0, true},
+		{"web-eu", "A", 0, true},
+	}
+	for _, test := range tests {
+		got, err := getPoolID(test.input, test.recordType)
+		if (err != nil) != test.wantErr {
+			t.Errorf("getPoolID(%v) error = %v, want error %v", test.input, err, test.wantErr)
+		}
+		if got != test.want {
+			t.Errorf("getPoolID(%v) = %v, want %v", test.input, got, test.want)
+		}
+	}
+}
+
+func TestExpectedDNSRecord_PoolReference_UnmarshalYAML(t *testing.T) {
+	oldGetPools := GetPools
+	defer func() { GetPools = oldGetPools }()
+	GetPools = func() ([]*Pool, error) {
+		return []*Pool{{ID: 1, Name: "web-eu", Type: "A"}}, nil
+	}
+
+	data := `
+name: www
+type: A
+mode: pools
+value:
+  - "@pool:web-eu"
+  - 5
+`
+	var obj ExpectedDNSRecord
+	err := yaml.Unmarshal([]byte(data), &obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []int{1, 5}
+	if !reflect.DeepEqual(obj.Value, expected) {
+		t.Errorf("expected %v, got %v", expected, obj.Value)
+	}
+
+	// Pools of the configuration must be references or IDs
+	err = yaml.Unmarshal([]byte("{name: www, type: A, mode: pools, value: [web-eu]}"), &obj)
+	if err == nil || err.Error() != "invalid pool value. Expected @pool:<name> or int" {
+		t.Errorf("expected invalid pool value error, got %v", err)
+	}
+}
+
+func TestDNSRecord_Pools_UnmarshalJSON(t *testing.T) {
+	// Pools of active records are not resolved, unexpected values are ignored
+	data := `{"id":1,"name":"www","type":"A","ttl":60,"mode":"pools","region":"default","enabled":true,"value":[7,{"id":8}]}`
+	var obj DNSRecord
+	err := json.Unmarshal([]byte(data), &obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []int{7, 0}
+	if !reflect.DeepEqual(obj.Value, expected) {
+		t.Errorf("expected %v, got %v", expected, obj.Value)
+	}
+}
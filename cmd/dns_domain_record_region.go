@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"golang.org/x/exp/slices"
+)
+
+// GTD (Global Traffic Director) regions supported by Constellix. Records in
+// non-default regions are served only if GTD is enabled for the domain
+var supportedGTDRegions = []string{
+	"default", "europe", "us-east", "us-west", "asia-pacific", "oceania", "south-america",
+}
+
+// validateDNSRecordRegion checks that the region of the record is a known GTD
+// region. Empty region means the default one
+func validateDNSRecordRegion(record *DNSRecord) error {
+	if record.Region == "" || slices.Contains(supportedGTDRegions, record.Region) {
+		return nil
+	}
+	return fmt.Errorf("unsupported region %q, expected one of %q", record.Region, supportedGTDRegions)
+}
+
+// warnGTDDisabled warns about records in non-default regions when GTD is
+// disabled for the domain
+func warnGTDDisabled(domain *DNSDomain, records []*ExpectedDNSRecord) {
+	if domain.GTDEnabled {
+		return
+	}
+	for _, record := range records {
+		if record.Region != "" && record.Region != "default" {
+			logger.Printf(
+				"WARNING: GTD is disabled for domain %s, record %q in region %s is not served\n",
+				domain.Name, record.GetResourceID(), record.Region,
+			)
+		}
+	}
+}
+
+// reportGTDRegions prints which GTD regions are covered by the records for
+// each name and type
+func reportGTDRegions(domainName string, records []*ExpectedDNSRecord) {
+	type nameType struct {
+		name       string
+		recordType string
+	}
+	coverage := make(map[nameType]map[string]bool)
+	var keys []nameType
+	for _, record := range records {
+		key := nameType{record.Name, record.Type}
+		if _, ok := coverage[key]; !ok {
+			coverage[key] = make(map[string]bool)
+			keys = append(keys, key)
+		}
+		region := record.Region
+		if region == "" {
+			region = "default"
+		}
+		coverage[key][region] = true
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].name == keys[j].name {
+			return keys[i].recordType < keys[j].recordType
+		}
+		return keys[i].name < keys[j].name
+	})
+
+	report := table.NewWriter()
+	if reportToTestBuffer {
+		// Skip header in tests
+		report.SetOutputMirror(testBuffer)
+	} else {
+		report.SetOutputMirror(os.Stdout)
+		report.SetTitle("GTD regions for " + domainName)
+		header := table.Row{"Name", "Type"}
+		for _, region := range supportedGTDRegions {
+			header = append(header, region)
+		}
+		report.AppendHeader(header)
+	}
+	for _, key := range keys {
+		row := table.Row{key.name, key.recordType}
+		for _, region := range supportedGTDRegions {
+			if coverage[key][region] {
+				row = append(row, "x")
+			} else {
+				row = append(row, "")
+			}
+		}
+		report.AppendRow(row)
+	}
+	printReport(report)
+}
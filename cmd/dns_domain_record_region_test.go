@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateDNSRecordRegion(t *testing.T) {
+	for _, region := range []string{"", "default", "europe", "asia-pacific"} {
+		err := validateDNSRecordRegion(&DNSRecord{Region: region})
+		if err != nil {
+			t.Errorf("region %q: unexpected error %s", region, err)
+		}
+	}
+	err := validateDNSRecordRegion(&DNSRecord{Region: "eurpe"})
+	if err == nil || !strings.HasPrefix(err.Error(), `unsupported region "eurpe"`) {
+		t.Errorf("expected unsupported region error, got %v", err)
+	}
+}
+
+func TestGetConfig_invalid_region(t *testing.T) {
+	configFile := writeTestConfig(t, map[string]string{
+		"main.yaml": `
+constellix:
+  dns:
+    example.com:
+      - records.yaml
+`,
+		"records.yaml": `
+- name: www
+  type: A
+  mode: standard
+  region: eurpe
+  value:
+    - value: 1.1.1.1
+      enabled: true
+`,
+	})
+	_, err := getConfig(configFile)
+	if err == nil || !strings.Contains(err.Error(), `records.yaml:2: A "www" (eurpe, 0): unsupported region "eurpe"`) {
+		t.Errorf("expected unsupported region error, got %v", err)
+	}
+}
+
+func TestReportGTDRegions(t *testing.T) {
+	records := []*ExpectedDNSRecord{
+		{DNSRecord: DNSRecord{Name: "www", Type: "A", Region: "europe"}},
+		{DNSRecord: DNSRecord{Name: "www", Type: "A"}},
+		{DNSRecord: DNSRecord{Name: "api", Type: "A", Region: "us-east"}},
+		{DNSRecord: DNSRecord{Name: "www", Type: "AAAA", Region: "default"}},
+	}
+
+	reportToTestBuffer = true
+	defer func() {
+		reportToTestBuffer = false
+		testBuffer.Reset()
+	}()
+	reportGTDRegions("example.com", records)
+
+	expected := "api,A,,,x,,,,\nwww,A,x,x,,,,,\nwww,AAAA,x,,,,,,\n"
+	if testBuffer.String() != expected {
+		t.Errorf("want %q, got %q", expected, testBuffer.String())
+	}
+}
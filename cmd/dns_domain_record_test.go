@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 
@@ -712,3 +714,453 @@ value:
 		}
 	}
 }
+
+func TestExpectedDNSRecord_SRV_Unmarshal(t *testing.T) {
+	dataY := `
+name: _sip._tcp
+type: SRV
+ttl: 3600
+mode: standard
+region: default
+enabled: true
+value:
+  - priority: 10
+    weight: 60
+    port: 5060
+    host: sip1.example.com.
+    enabled: true
+  - priority: 20
+    weight: 0
+    port: 5060
+    host: sip2.example.com.
+    enabled: false
+`
+	dataJ := `{"id":58345379,"name":"_sip._tcp","type":"SRV","ttl":3600,"mode":"standard","region":"default","enabled":true,"value":[{"priority":10,"weight":60,"port":5060,"host":"sip1.example.com.","enabled":true},{"priority":20,"weight":0,"port":5060,"host":"sip2.example.com.","enabled":false}]}`
+
+	expectedValue := []*DNSSRVStandardItemValue{
+		{Priority: 10, Weight: 60, Port: 5060, Host: "sip1.example.com.", Enabled: true},
+		{Priority: 20, Weight: 0, Port: 5060, Host: "sip2.example.com.", Enabled: false},
+	}
+
+	var objY ExpectedDNSRecord
+	err := yaml.Unmarshal([]byte(dataY), &objY)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var objJ DNSRecord
+	err = json.Unmarshal([]byte(dataJ), &objJ)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, value := range []interface{}{objY.Value, objJ.Value} {
+		res, ok := value.([]*DNSSRVStandardItemValue)
+		if !ok {
+			t.Fatalf("unexpected type %T", value)
+		}
+		if !reflect.DeepEqual(res, expectedValue) {
+			t.Errorf("expected %v, got %v", expectedValue, res)
+		}
+	}
+
+	action, _, err := Compare(&objY, &objJ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if action != ActionOK {
+		t.Errorf("expected action '%v', got '%v'", ActionOK, action)
+	}
+
+	payload, err := generatePayload(&objY, []string{"value"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedPayload := `{"value":[{"enabled":true,"host":"sip1.example.com.","port":5060,"priority":10,"weight":60},{"enabled":false,"host":"sip2.example.com.","port":5060,"priority":20,"weight":0}]}`
+	if string(payload) != expectedPayload {
+		t.Errorf("expected %q, got %q", expectedPayload, string(payload))
+	}
+}
+
+func TestExpectedDNSRecord_NS_Unmarshal(t *testing.T) {
+	dataY := `
+name: sub
+type: NS
+ttl: 86400
+mode: standard
+value:
+  - value: ns1.other-provider.net.
+    enabled: true
+  - value: ns2.other-provider.net.
+    enabled: true
+`
+	dataJ := `{"id":58345380,"name":"sub","type":"NS","ttl":86400,"mode":"standard","region":"default","enabled":true,"value":[{"value":"ns1.other-provider.net.","enabled":true},{"value":"ns2.other-provider.net.","enabled":true}]}`
+
+	expectedValue := []*DNSStandardItemValue{
+		{Value: "ns1.other-provider.net.", Enabled: true},
+		{Value: "ns2.other-provider.net.", Enabled: true},
+	}
+
+	var objY ExpectedDNSRecord
+	err := yaml.Unmarshal([]byte(dataY), &objY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var objJ DNSRecord
+	err = json.Unmarshal([]byte(dataJ), &objJ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, value := range []interface{}{objY.Value, objJ.Value} {
+		if !reflect.DeepEqual(value, expectedValue) {
+			t.Errorf("expected %v, got %v", expectedValue, value)
+		}
+	}
+}
+
+func TestExpectedDNSRecord_NS_invalid_nameserver(t *testing.T) {
+	data := `
+name: sub
+type: NS
+mode: standard
+value:
+  - value: "ns1 other-provider.net"
+    enabled: true
+`
+	var obj ExpectedDNSRecord
+	err := yaml.Unmarshal([]byte(data), &obj)
+	expected := "invalid nameserver \"ns1 other-provider.net\" in NS record, expected a hostname"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+}
+
+func TestExpectedDNSRecord_TXT_invalid_value(t *testing.T) {
+	tests := map[string]string{
+		"value: 42\n    enabled: true":       "unable to parse value for TXT record in standard mode, expected a string value",
+		"value: \"a\"\n    enabled: \"yes\"": "unable to parse value for TXT record in standard mode, expected a boolean enabled",
+	}
+	for item, expected := range tests {
+		data := "name: txt\ntype: TXT\nmode: standard\nvalue:\n  - " + item + "\n"
+		var obj ExpectedDNSRecord
+		err := yaml.Unmarshal([]byte(data), &obj)
+		if err == nil || err.Error() != expected {
+			t.Errorf("expected error %q, got %v", expected, err)
+		}
+	}
+}
+
+func TestFilterProtectedDNSRecords(t *testing.T) {
+	active := []*DNSRecord{
+		{Name: "", Type: "NS", Region: "default"},
+		{Name: "sub", Type: "NS", Region: "default"},
+		{Name: "", Type: "A", Region: "default"},
+	}
+	filtered := filterProtectedDNSRecords(active, nil)
+	if len(filtered) != 2 || filtered[0].Name != "sub" || filtered[1].Type != "A" {
+		t.Errorf("expected apex NS record to be filtered out, got %v", filtered)
+	}
+
+	expected := []*ExpectedDNSRecord{
+		{DNSRecord: DNSRecord{Name: "", Type: "NS", Region: "default"}},
+	}
+	filtered = filterProtectedDNSRecords(active, expected)
+	if len(filtered) != 3 {
+		t.Errorf("expected configured apex NS record to be kept, got %v", filtered)
+	}
+}
+
+func TestGetDNSRecords_other_types(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[
+			{"id":1,"name":"1","type":"PTR","ttl":3600,"mode":"standard","region":"default","enabled":true,"value":[{"value":"host.example.com.","enabled":true}]},
+			{"id":2,"name":"","type":"SPF","ttl":3600,"mode":"standard","region":"default","enabled":true,"value":[{"value":"\"v=spf1 -all\"","enabled":true}]},
+			{"id":3,"name":"host","type":"HINFO","ttl":3600,"mode":"standard","region":"default","enabled":true,"value":[{"cpu":"x86","os":"linux","enabled":true}]},
+			{"id":4,"name":"","type":"RP","ttl":3600,"mode":"standard","region":"default","enabled":true,"value":[{"mailbox":"admin.example.com.","txt":"info.example.com.","enabled":true}]},
+			{"id":5,"name":"","type":"CERT","ttl":3600,"mode":"standard","region":"default","enabled":true,"value":[{"certificateType":1,"keyTag":12345,"algorithm":8,"certificate":"TUlJQg==","enabled":false}]}
+		],"meta":{"pagination":{"total":5,"count":5,"perPage":100,"currentPage":1,"totalPages":1}}}`))
+	}))
+	defer ts.Close()
+
+	originalDNSRESTAPIBaseURL := dnsRESTAPIBaseURL
+	defer func() {
+		dnsRESTAPIBaseURL = originalDNSRESTAPIBaseURL
+	}()
+	dnsRESTAPIBaseURL = ts.URL
+
+	records, err := GetDNSRecords(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []interface{}{
+		[]*DNSStandardItemValue{{Value: "host.example.com.", Enabled: true}},
+		[]*DNSStandardItemValue{{Value: "\"v=spf1 -all\"", Enabled: true}},
+		[]*DNSHINFOStandardItemValue{{CPU: "x86", OS: "linux", Enabled: true}},
+		[]*DNSRPStandardItemValue{{Mailbox: "admin.example.com.", TXT: "info.example.com.", Enabled: true}},
+		[]*DNSCERTStandardItemValue{{CertificateType: 1, KeyTag: 12345, Algorithm: 8, Certificate: "TUlJQg==", Enabled: false}},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("expected %d records, got %d", len(expected), len(records))
+	}
+	for idx, record := range records {
+		if !reflect.DeepEqual(record.Value, expected[idx]) {
+			t.Errorf("%s: expected %v, got %v", record.Type, expected[idx], record.Value)
+		}
+	}
+}
+
+func TestExpectedDNSRecord_CERT_UnmarshalYAML(t *testing.T) {
+	data := `
+name: ""
+type: CERT
+mode: standard
+value:
+  - certificateType: 1
+    keyTag: 12345
+    algorithm: 8
+    certificate: TUlJQg==
+    enabled: true
+`
+	var obj ExpectedDNSRecord
+	err := yaml.Unmarshal([]byte(data), &obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*DNSCERTStandardItemValue{{CertificateType: 1, KeyTag: 12345, Algorithm: 8, Certificate: "TUlJQg==", Enabled: true}}
+	if !reflect.DeepEqual(obj.Value, expected) {
+		t.Errorf("expected %v, got %v", expected, obj.Value)
+	}
+}
+
+func TestExpectedDNSRecord_NAPTR_UnmarshalYAML(t *testing.T) {
+	data := `
+name: ""
+type: NAPTR
+mode: standard
+value:
+  - order: 100
+    preference: 10
+    flags: S
+    service: SIP+D2U
+    regularExpression: ""
+    replacement: _sip._udp.example.com.
+    enabled: true
+  - order: 100
+    preference: 20
+    flags: U
+    service: E2U+sip
+    regularExpression: "!^.*$!sip:info@example.com!"
+    replacement: .
+    enabled: true
+`
+	var obj ExpectedDNSRecord
+	err := yaml.Unmarshal([]byte(data), &obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*DNSNAPTRStandardItemValue{
+		{Order: 100, Preference: 10, Flags: "S", Service: "SIP+D2U", Replacement: "_sip._udp.example.com.", Enabled: true},
+		{Order: 100, Preference: 20, Flags: "U", Service: "E2U+sip", Regexp: "!^.*$!sip:info@example.com!", Replacement: ".", Enabled: true},
+	}
+	if !reflect.DeepEqual(obj.Value, expected) {
+		t.Errorf("expected %v, got %v", expected, obj.Value)
+	}
+}
+
+func TestDNSNAPTRStandardItemValue_Validate(t *testing.T) {
+	tests := []struct {
+		value    DNSNAPTRStandardItemValue
+		expected string
+	}{
+		{DNSNAPTRStandardItemValue{Order: 65536}, "order must be in range 0-65535, got 65536"},
+		{DNSNAPTRStandardItemValue{Preference: -1}, "preference must be in range 0-65535, got -1"},
+		{DNSNAPTRStandardItemValue{Flags: "S+"}, `flags must contain only alphanumeric characters, got "S+"`},
+		{DNSNAPTRStandardItemValue{Regexp: "!^.*$!sip:info@example.com!", Replacement: "example.com."}, "regular expression and replacement are mutually exclusive"},
+		{DNSNAPTRStandardItemValue{Regexp: "!^.*$!sip:info@example.com"}, `regular expression must have format !regexp!substitution!flags, got "!^.*$!sip:info@example.com"`},
+		{DNSNAPTRStandardItemValue{Regexp: "!^.*$!sip:info@example.com!x"}, `unsupported regular expression flags "x"`},
+		{DNSNAPTRStandardItemValue{Regexp: "!^(.*$!sip:info@example.com!"}, "invalid regular expression \"^(.*$\": error parsing regexp: missing closing ): `^(.*$`"},
+		{DNSNAPTRStandardItemValue{Regexp: "!^.*$!sip:info@example.com!i"}, ""},
+	}
+	for _, test := range tests {
+		err := test.value.Validate()
+		errStr := ""
+		if err != nil {
+			errStr = err.Error()
+		}
+		if errStr != test.expected {
+			t.Errorf("expected error %q, got %q", test.expected, errStr)
+		}
+	}
+}
+
+func TestGetDNSRecords_unmanaged(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[
+			{"id":1,"name":"_25._tcp","type":"TLSA","ttl":3600,"mode":"standard","region":"default","enabled":true,"value":[{"usage":3,"selector":1,"matchingType":1,"certificate":"abcd","enabled":true}]},
+			{"id":2,"name":"www","type":"A","ttl":3600,"mode":"standard","region":"default","enabled":true,"value":[{"value":"1.1.1.1","enabled":true}]}
+		],"meta":{"pagination":{"total":2,"count":2,"perPage":100,"currentPage":1,"totalPages":1}}}`))
+	}))
+	defer ts.Close()
+
+	originalDNSRESTAPIBaseURL := dnsRESTAPIBaseURL
+	defer func() {
+		dnsRESTAPIBaseURL = originalDNSRESTAPIBaseURL
+	}()
+	dnsRESTAPIBaseURL = ts.URL
+
+	records, err := GetDNSRecords(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if !records[0].IsUnmanaged() {
+		t.Errorf("expected TLSA record to be unmanaged")
+	}
+	if records[1].IsUnmanaged() {
+		t.Errorf("expected A record to be managed")
+	}
+
+	dataBytes, err := yaml.Marshal(records[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `- id: 1
+  name: _25._tcp
+  type: TLSA
+  ttl: 3600
+  mode: standard
+  region: default
+  enabled: true
+  value:
+    - usage: 3
+      selector: 1
+      matchingType: 1
+      certificate: abcd
+      enabled: true
+`
+	if string(dataBytes) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, string(dataBytes))
+	}
+}
+
+func TestExpectedDNSRecord_MXFailover_UnmarshalJSON(t *testing.T) {
+	data := `{"id":12494734,"name":"","type":"MX","ttl":300,"mode":"failover","region":"default","enabled":true,"value":{"enabled":true,"mode":"normal","values":[{"server":"mx1.example.com.","priority":10,"order":1,"sonarCheckId":84874,"enabled":true,"active":true,"failed":false,"status":"UP"},{"server":"mx2.example.com.","priority":20,"order":2,"sonarCheckId":null,"enabled":true,"active":false,"failed":false,"status":"N\/A"}]}}`
+	var obj DNSRecord
+	err := json.Unmarshal([]byte(data), &obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &DNSMXFailoverValue{
+		Mode:    "normal",
+		Enabled: true,
+		Values: []*DNSMXFailoverItemValue{
+			{Enabled: true, Order: 1, SonarCheckID: 84874, Server: "mx1.example.com.", Priority: 10},
+			{Enabled: true, Order: 2, Server: "mx2.example.com.", Priority: 20},
+		},
+	}
+	if !reflect.DeepEqual(obj.Value, expected) {
+		t.Errorf("expected %v, got %v", expected, obj.Value)
+	}
+}
+
+func TestExpectedDNSRecord_TXTFailover_UnmarshalYAML(t *testing.T) {
+	data := `
+name: status
+type: TXT
+mode: failover
+value:
+  enabled: true
+  mode: normal
+  values:
+    - value: "\"primary\""
+      order: 1
+      sonarCheckId: 84874
+      enabled: true
+    - value: "\"secondary\""
+      order: 2
+      enabled: true
+`
+	var obj ExpectedDNSRecord
+	err := yaml.Unmarshal([]byte(data), &obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &DNSFailoverValue{
+		Mode:    "normal",
+		Enabled: true,
+		Values: []*DNSFailoverItemValue{
+			{Enabled: true, Order: 1, SonarCheckID: 84874, Value: "\"primary\""},
+			{Enabled: true, Order: 2, Value: "\"secondary\""},
+		},
+	}
+	if !reflect.DeepEqual(obj.Value, expected) {
+		t.Errorf("expected %v, got %v", expected, obj.Value)
+	}
+}
+
+func TestExpectedDNSRecord_unsupported_failover_mode(t *testing.T) {
+	tests := []struct {
+		data     string
+		expected string
+	}{
+		{
+			`{type: CNAME, mode: roundrobin-failover, value: [{value: example.com., order: 1, enabled: true}]}`,
+			"roundrobin-failover mode is not supported for CNAME records",
+		},
+		{
+			`{type: SPF, mode: failover, value: {enabled: true, mode: normal, values: []}}`,
+			"failover mode is not supported for SPF records",
+		},
+	}
+	for _, test := range tests {
+		var obj ExpectedDNSRecord
+		err := yaml.Unmarshal([]byte(test.data), &obj)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("expected error %q, got %v", test.expected, err)
+		}
+	}
+}
+
+func TestDNSRecord_extra_fields_round_trip(t *testing.T) {
+	data := `{"id":31847357,"name":"abc","type":"CNAME","ttl":600,"mode":"standard","region":"default","enabled":true,"value":[{"value":"example.com.","enabled":true}],"lastValues":{"standard":[{"value":"example.org.","enabled":true}],"failover":{"enabled":false,"mode":"normal","values":[]},"pools":[]},"notes":"","skipLookup":true,"contacts":[{"id":7,"name":"ops"}]}`
+	var active DNSRecord
+	err := json.Unmarshal([]byte(data), &active)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(active.Contacts, []int{7}) {
+		t.Errorf("expected contacts %v, got %v", []int{7}, active.Contacts)
+	}
+	if !active.SkipLookup {
+		t.Errorf("expected skipLookup to be true")
+	}
+
+	// Discovered record is used as configuration without changes
+	dataBytes, err := yaml.Marshal(&active)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected ExpectedDNSRecord
+	err = yaml.Unmarshal(dataBytes, &expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	action, diffs, err := Compare(&expected, &active)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if action != ActionOK {
+		t.Errorf("expected action %q, got %q: %v", ActionOK, action, diffs)
+	}
+
+	payload, err := generatePayload(&expected, []string{"contacts", "skipLookup", "lastValues"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedPayload := `{"contacts":[7],"lastValues":{"failover":{"enabled":false,"mode":"normal","values":[]},"pools":[],"standard":[{"enabled":true,"value":"example.org."}]},"skipLookup":true}`
+	if string(payload) != expectedPayload {
+		t.Errorf("expected payload %s, got %s", expectedPayload, string(payload))
+	}
+}
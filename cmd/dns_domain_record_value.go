@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"regexp"
 	"strings"
+
+	"golang.org/x/exp/slices"
 )
 
 type DNSStandardItemValue struct {
@@ -23,6 +28,20 @@ type DNSFailoverItemValue struct {
 	Value        string `json:"value" yaml:"value"`
 }
 
+type DNSMXFailoverValue struct {
+	Mode    string                    `json:"mode" yaml:"mode"`
+	Enabled bool                      `json:"enabled" yaml:"enabled"`
+	Values  []*DNSMXFailoverItemValue `json:"values" yaml:"values"`
+}
+
+type DNSMXFailoverItemValue struct {
+	Enabled      bool   `json:"enabled" yaml:"enabled"`
+	Order        int    `json:"order" yaml:"order"`
+	SonarCheckID int    `json:"sonarCheckId" yaml:"sonarCheckId"`
+	Server       string `json:"server" yaml:"server"`
+	Priority     int    `json:"priority" yaml:"priority"`
+}
+
 type DNSMXStandardItemValue struct {
 	Server   string `json:"server" yaml:"server"`
 	Priority int    `json:"priority" yaml:"priority"`
@@ -36,6 +55,44 @@ type DNSCAAStandardItemValue struct {
 	Enabled bool   `json:"enabled" yaml:"enabled"`
 }
 
+type DNSSRVStandardItemValue struct {
+	Priority int    `json:"priority" yaml:"priority"`
+	Weight   int    `json:"weight" yaml:"weight"`
+	Port     int    `json:"port" yaml:"port"`
+	Host     string `json:"host" yaml:"host"`
+	Enabled  bool   `json:"enabled" yaml:"enabled"`
+}
+
+type DNSHINFOStandardItemValue struct {
+	CPU     string `json:"cpu" yaml:"cpu"`
+	OS      string `json:"os" yaml:"os"`
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+}
+
+type DNSRPStandardItemValue struct {
+	Mailbox string `json:"mailbox" yaml:"mailbox"`
+	TXT     string `json:"txt" yaml:"txt"`
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+}
+
+type DNSCERTStandardItemValue struct {
+	CertificateType int    `json:"certificateType" yaml:"certificateType"`
+	KeyTag          int    `json:"keyTag" yaml:"keyTag"`
+	Algorithm       int    `json:"algorithm" yaml:"algorithm"`
+	Certificate     string `json:"certificate" yaml:"certificate"`
+	Enabled         bool   `json:"enabled" yaml:"enabled"`
+}
+
+type DNSNAPTRStandardItemValue struct {
+	Order       int    `json:"order" yaml:"order"`
+	Preference  int    `json:"preference" yaml:"preference"`
+	Flags       string `json:"flags" yaml:"flags"`
+	Service     string `json:"service" yaml:"service"`
+	Regexp      string `json:"regularExpression" yaml:"regularExpression"`
+	Replacement string `json:"replacement" yaml:"replacement"`
+	Enabled     bool   `json:"enabled" yaml:"enabled"`
+}
+
 type DNSHTTPStandardItemValue struct {
 	Hard         bool   `json:"hard" yaml:"hard"`
 	RedirectType string `json:"redirectType" yaml:"redirectType"`
@@ -47,6 +104,25 @@ type DNSHTTPStandardItemValue struct {
 
 type aliasDNSRecord DNSRecord
 
+// errUnsupportedRecordType is returned when the value of the record can't be
+// parsed because its type is not supported
+var errUnsupportedRecordType = errors.New("unsupported record type")
+
+// dnsRecordFailoverModes lists record types which support failover modes in
+// Constellix
+var dnsRecordFailoverModes = map[string][]string{
+	"A":     {"failover", "roundrobin-failover"},
+	"AAAA":  {"failover", "roundrobin-failover"},
+	"ANAME": {"failover"},
+	"CNAME": {"failover"},
+	"MX":    {"failover", "roundrobin-failover"},
+	"TXT":   {"failover"},
+}
+
+var naptrFlagsRe = regexp.MustCompile(`^[a-zA-Z0-9]*$`)
+
+var hostnameRe = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)*[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.?$`)
+
 // populateDNSRecordValue populates the Value field of a DNSRecord based on the
 // Mode field.
 // TODO: be carefull with type casting, use similar to sonarCheckID everywhere
@@ -77,66 +153,17 @@ func populateDNSRecordValue(record interface{}) error {
 			}
 			s.Value = valueObj
 		case "failover":
-			valueObj := DNSFailoverValue{}
-			m, ok := s.Value.(map[string]interface{})
-			if !ok {
-				return fmt.Errorf("unable to parse value for failover mode, expected an map")
-			}
-			valueObj.Mode = m["mode"].(string)
-			valueObj.Enabled = m["enabled"].(bool)
-			values := make([]*DNSFailoverItemValue, 0)
-			for _, valueItem := range m["values"].([]interface{}) {
-				valueItemMap, ok := valueItem.(map[string]interface{})
-				if !ok {
-					return fmt.Errorf("unable to parse value for value of failover mode, expected an map")
-				}
-				sonarCheckID, sonarCheckHost, err := getSonarCheckID(valueItemMap["sonarCheckId"])
-				if err != nil {
-					return err
-				}
-				if sonarCheckHost == "" {
-					sonarCheckHost = valueItemMap["value"].(string)
-				}
-				valueItemObj := DNSFailoverItemValue{
-					Enabled:      valueItemMap["enabled"].(bool),
-					Order:        toInt(valueItemMap["order"]),
-					Value:        sonarCheckHost,
-					SonarCheckID: sonarCheckID,
-				}
-				values = append(values, &valueItemObj)
+			mode, enabled, values, err := parseDNSFailoverValue(s, parseDNSFailoverItem)
+			if err != nil {
+				return err
 			}
-			valueObj.Values = values
-			s.Value = &valueObj
+			s.Value = &DNSFailoverValue{Mode: mode, Enabled: enabled, Values: values}
 		case "roundrobin-failover":
-			if s.Type == "CNAME" || s.Type == "ANAME" {
-				return fmt.Errorf("roundrobin-failover is not supported for CNAME records")
-			}
-			m, ok := s.Value.([]interface{})
-			if !ok {
-				return fmt.Errorf("unable to parse value for roundrobin-failover mode, expected an array")
-			}
-			valueObj := make([]*DNSFailoverItemValue, 0)
-			for _, el := range m {
-				elMap, ok := el.(map[string]interface{})
-				if !ok {
-					return fmt.Errorf("unable to parse value for roundrobin-failover mode, expected an map")
-				}
-				sonarCheckID, sonarCheckHost, err := getSonarCheckID(elMap["sonarCheckId"])
-				if err != nil {
-					return err
-				}
-				if sonarCheckHost == "" {
-					sonarCheckHost = elMap["value"].(string)
-				}
-				valueEl := DNSFailoverItemValue{
-					Enabled:      elMap["enabled"].(bool),
-					Order:        toInt(elMap["order"]),
-					Value:        sonarCheckHost,
-					SonarCheckID: sonarCheckID,
-				}
-				valueObj = append(valueObj, &valueEl)
+			values, err := parseDNSFailoverItems(s, s.Value, parseDNSFailoverItem)
+			if err != nil {
+				return err
 			}
-			s.Value = valueObj
+			s.Value = values
 		case "pools":
 			m, ok := s.Value.([]interface{})
 			if !ok {
@@ -151,6 +178,22 @@ func populateDNSRecordValue(record interface{}) error {
 			return fmt.Errorf("unknown mode %q", s.Mode)
 		}
 	case "MX":
+		switch s.Mode {
+		case "failover":
+			mode, enabled, values, err := parseDNSFailoverValue(s, parseDNSMXFailoverItem)
+			if err != nil {
+				return err
+			}
+			s.Value = &DNSMXFailoverValue{Mode: mode, Enabled: enabled, Values: values}
+			return nil
+		case "roundrobin-failover":
+			values, err := parseDNSFailoverItems(s, s.Value, parseDNSMXFailoverItem)
+			if err != nil {
+				return err
+			}
+			s.Value = values
+			return nil
+		}
 		if s.Mode != "standard" {
 			return fmt.Errorf("unsupported mode %q for MX record", s.Mode)
 		}
@@ -172,27 +215,69 @@ func populateDNSRecordValue(record interface{}) error {
 			valueObj = append(valueObj, &valueEl)
 		}
 		s.Value = valueObj
-	case "TXT":
+	case "TXT", "SPF", "PTR":
+		if s.Mode == "failover" {
+			mode, enabled, values, err := parseDNSFailoverValue(s, parseDNSTXTFailoverItem)
+			if err != nil {
+				return err
+			}
+			s.Value = &DNSFailoverValue{Mode: mode, Enabled: enabled, Values: values}
+			return nil
+		}
 		if s.Mode != "standard" {
-			return fmt.Errorf("unsupported mode %q for TXT record", s.Mode)
+			return fmt.Errorf("unsupported mode %q for %s record", s.Mode, s.Type)
 		}
 		m, ok := s.Value.([]interface{})
 		if !ok {
-			return fmt.Errorf("unable to parse value for TXT record in standard mode, expected an array")
+			return fmt.Errorf("unable to parse value for %s record in standard mode, expected an array", s.Type)
 		}
 		valueObj := make([]*DNSStandardItemValue, 0)
 		for _, el := range m {
 			elMap, ok := el.(map[string]interface{})
 			if !ok {
-				return fmt.Errorf("unable to parse value for TXT record in standard mode, expected an map")
+				return fmt.Errorf("unable to parse value for %s record in standard mode, expected an map", s.Type)
+			}
+			value, ok := elMap["value"].(string)
+			if !ok {
+				return fmt.Errorf("unable to parse value for %s record in standard mode, expected a string value", s.Type)
+			}
+			enabled, ok := elMap["enabled"].(bool)
+			if !ok && elMap["enabled"] != nil {
+				return fmt.Errorf("unable to parse value for %s record in standard mode, expected a boolean enabled", s.Type)
 			}
 			valueEl := DNSStandardItemValue{
-				Value:   elMap["value"].(string),
-				Enabled: elMap["enabled"].(bool),
+				Value:   value,
+				Enabled: enabled,
 			}
 			valueObj = append(valueObj, &valueEl)
-			s.Value = valueObj
 		}
+		s.Value = valueObj
+	case "NS":
+		if s.Mode != "standard" {
+			return fmt.Errorf("unsupported mode %q for NS record", s.Mode)
+		}
+		m, ok := s.Value.([]interface{})
+		if !ok {
+			return fmt.Errorf("unable to parse value for NS record in standard mode, expected an array")
+		}
+		valueObj := make([]*DNSStandardItemValue, 0)
+		for _, el := range m {
+			elMap, ok := el.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("unable to parse value for NS record in standard mode, expected a map")
+			}
+			value, _ := elMap["value"].(string)
+			if !hostnameRe.MatchString(value) {
+				return fmt.Errorf("invalid nameserver %q in NS record, expected a hostname", value)
+			}
+			enabled, _ := elMap["enabled"].(bool)
+			valueEl := DNSStandardItemValue{
+				Value:   value,
+				Enabled: enabled,
+			}
+			valueObj = append(valueObj, &valueEl)
+		}
+		s.Value = valueObj
 	case "HTTP":
 		if s.Mode != "standard" {
 			return fmt.Errorf("unsupported mode %q for HTTP record", s.Mode)
@@ -232,8 +317,198 @@ func populateDNSRecordValue(record interface{}) error {
 			valueObj = append(valueObj, &valueEl)
 		}
 		s.Value = valueObj
+	case "SRV":
+		if s.Mode != "standard" {
+			return fmt.Errorf("unsupported mode %q for SRV record", s.Mode)
+		}
+		m, ok := s.Value.([]interface{})
+		if !ok {
+			return fmt.Errorf("unable to parse value for SRV record in standard mode, expected an array")
+		}
+		valueObj := make([]*DNSSRVStandardItemValue, 0)
+		for _, el := range m {
+			elMap, ok := el.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("unable to parse value for SRV record in standard mode, expected a map")
+			}
+			host, ok := elMap["host"].(string)
+			if !ok {
+				return fmt.Errorf("unable to parse value for SRV record in standard mode, expected a host")
+			}
+			enabled, _ := elMap["enabled"].(bool)
+			valueEl := DNSSRVStandardItemValue{
+				Priority: toInt(elMap["priority"]),
+				Weight:   toInt(elMap["weight"]),
+				Port:     toInt(elMap["port"]),
+				Host:     host,
+				Enabled:  enabled,
+			}
+			valueObj = append(valueObj, &valueEl)
+		}
+		s.Value = valueObj
+	case "HINFO":
+		if s.Mode != "standard" {
+			return fmt.Errorf("unsupported mode %q for HINFO record", s.Mode)
+		}
+		m, ok := s.Value.([]interface{})
+		if !ok {
+			return fmt.Errorf("unable to parse value for HINFO record in standard mode, expected an array")
+		}
+		valueObj := make([]*DNSHINFOStandardItemValue, 0)
+		for _, el := range m {
+			elMap, ok := el.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("unable to parse value for HINFO record in standard mode, expected a map")
+			}
+			valueEl := DNSHINFOStandardItemValue{}
+			valueEl.CPU, _ = elMap["cpu"].(string)
+			valueEl.OS, _ = elMap["os"].(string)
+			valueEl.Enabled, _ = elMap["enabled"].(bool)
+			valueObj = append(valueObj, &valueEl)
+		}
+		s.Value = valueObj
+	case "RP":
+		if s.Mode != "standard" {
+			return fmt.Errorf("unsupported mode %q for RP record", s.Mode)
+		}
+		m, ok := s.Value.([]interface{})
+		if !ok {
+			return fmt.Errorf("unable to parse value for RP record in standard mode, expected an array")
+		}
+		valueObj := make([]*DNSRPStandardItemValue, 0)
+		for _, el := range m {
+			elMap, ok := el.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("unable to parse value for RP record in standard mode, expected a map")
+			}
+			valueEl := DNSRPStandardItemValue{}
+			valueEl.Mailbox, _ = elMap["mailbox"].(string)
+			valueEl.TXT, _ = elMap["txt"].(string)
+			valueEl.Enabled, _ = elMap["enabled"].(bool)
+			valueObj = append(valueObj, &valueEl)
+		}
+		s.Value = valueObj
+	case "CERT":
+		if s.Mode != "standard" {
+			return fmt.Errorf("unsupported mode %q for CERT record", s.Mode)
+		}
+		m, ok := s.Value.([]interface{})
+		if !ok {
+			return fmt.Errorf("unable to parse value for CERT record in standard mode, expected an array")
+		}
+		valueObj := make([]*DNSCERTStandardItemValue, 0)
+		for _, el := range m {
+			elMap, ok := el.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("unable to parse value for CERT record in standard mode, expected a map")
+			}
+			valueEl := DNSCERTStandardItemValue{
+				CertificateType: toInt(elMap["certificateType"]),
+				KeyTag:          toInt(elMap["keyTag"]),
+				Algorithm:       toInt(elMap["algorithm"]),
+			}
+			valueEl.Certificate, _ = elMap["certificate"].(string)
+			valueEl.Enabled, _ = elMap["enabled"].(bool)
+			valueObj = append(valueObj, &valueEl)
+		}
+		s.Value = valueObj
+	case "NAPTR":
+		if s.Mode != "standard" {
+			return fmt.Errorf("unsupported mode %q for NAPTR record", s.Mode)
+		}
+		m, ok := s.Value.([]interface{})
+		if !ok {
+			return fmt.Errorf("unable to parse value for NAPTR record in standard mode, expected an array")
+		}
+		valueObj := make([]*DNSNAPTRStandardItemValue, 0)
+		for _, el := range m {
+			elMap, ok := el.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("unable to parse value for NAPTR record in standard mode, expected a map")
+			}
+			valueEl := DNSNAPTRStandardItemValue{
+				Order:      toInt(elMap["order"]),
+				Preference: toInt(elMap["preference"]),
+			}
+			valueEl.Flags, _ = elMap["flags"].(string)
+			valueEl.Service, _ = elMap["service"].(string)
+			valueEl.Regexp, _ = elMap["regularExpression"].(string)
+			valueEl.Replacement, _ = elMap["replacement"].(string)
+			valueEl.Enabled, _ = elMap["enabled"].(bool)
+			valueObj = append(valueObj, &valueEl)
+		}
+		s.Value = valueObj
 	default:
-		return fmt.Errorf("unsupported record type %q", s.Type)
+		return fmt.Errorf("%w %q", errUnsupportedRecordType, s.Type)
+	}
+	return nil
+}
+
+// populateDNSRecordLastValuesForYAML converts lastValues (values of other
+// modes) from the local YAML configuration to the same types as in the JSON
+// response from the API, so they can be compared
+func populateDNSRecordLastValuesForYAML(record *DNSRecord) error {
+	if record.LastValues == nil {
+		return nil
+	}
+	dataBytes, err := json.Marshal(record.LastValues)
+	if err != nil {
+		return fmt.Errorf("unable to parse value for lastValues: %s", err)
+	}
+	var lastValues interface{}
+	err = json.Unmarshal(dataBytes, &lastValues)
+	if err != nil {
+		return fmt.Errorf("unable to parse value for lastValues: %s", err)
+	}
+	record.LastValues = lastValues
+	return nil
+}
+
+// validateDNSRecordValue performs validation of the value of the record from
+// the local configuration
+func validateDNSRecordValue(record *DNSRecord) error {
+	switch v := record.Value.(type) {
+	case []*DNSNAPTRStandardItemValue:
+		for _, item := range v {
+			err := item.Validate()
+			if err != nil {
+				return fmt.Errorf("invalid NAPTR record %q: %s", record.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks ranges of the fields and syntax of the regular expression
+// (RFC 3403)
+func (v *DNSNAPTRStandardItemValue) Validate() error {
+	if v.Order < 0 || v.Order > 65535 {
+		return fmt.Errorf("order must be in range 0-65535, got %d", v.Order)
+	}
+	if v.Preference < 0 || v.Preference > 65535 {
+		return fmt.Errorf("preference must be in range 0-65535, got %d", v.Preference)
+	}
+	if !naptrFlagsRe.MatchString(v.Flags) {
+		return fmt.Errorf("flags must contain only alphanumeric characters, got %q", v.Flags)
+	}
+	if v.Regexp == "" {
+		return nil
+	}
+	if v.Replacement != "" && v.Replacement != "." {
+		return fmt.Errorf("regular expression and replacement are mutually exclusive")
+	}
+	// Format: <delim>ere<delim>substitution<delim>flags
+	delim := v.Regexp[:1]
+	parts := strings.Split(v.Regexp[1:], delim)
+	if len(parts) != 3 {
+		return fmt.Errorf("regular expression must have format %sregexp%ssubstitution%sflags, got %q", delim, delim, delim, v.Regexp)
+	}
+	if parts[2] != "" && parts[2] != "i" {
+		return fmt.Errorf("unsupported regular expression flags %q", parts[2])
+	}
+	_, err := regexp.Compile(parts[0])
+	if err != nil {
+		return fmt.Errorf("invalid regular expression %q: %s", parts[0], err)
 	}
 	return nil
 }
@@ -249,6 +524,85 @@ func toInt(i interface{}) int {
 	}
 }
 
+// parseDNSFailoverValue parses the value of the record in failover mode: mode
+// and state of the failover with the list of values
+func parseDNSFailoverValue[T any](
+	s *DNSRecord, parseItem func(map[string]interface{}, int, string) T,
+) (string, bool, []T, error) {
+	m, ok := s.Value.(map[string]interface{})
+	if !ok {
+		return "", false, nil, fmt.Errorf("unable to parse value for failover mode, expected an map")
+	}
+	values, err := parseDNSFailoverItems(s, m["values"], parseItem)
+	if err != nil {
+		return "", false, nil, err
+	}
+	mode, _ := m["mode"].(string)
+	enabled, _ := m["enabled"].(bool)
+	return mode, enabled, values, nil
+}
+
+// parseDNSFailoverItems parses the list of values of the record in failover
+// and roundrobin-failover modes. Sonar checks are resolved first, the host of
+// the check is passed to parseItem to be used as the value
+func parseDNSFailoverItems[T any](
+	s *DNSRecord, value interface{}, parseItem func(map[string]interface{}, int, string) T,
+) ([]T, error) {
+	if !slices.Contains(dnsRecordFailoverModes[s.Type], s.Mode) {
+		return nil, fmt.Errorf("%s mode is not supported for %s records", s.Mode, s.Type)
+	}
+	m, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unable to parse value for %s mode, expected an array", s.Mode)
+	}
+	values := make([]T, 0)
+	for _, el := range m {
+		elMap, ok := el.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unable to parse value for %s mode, expected an map", s.Mode)
+		}
+		sonarCheckID, sonarCheckHost, err := getSonarCheckID(elMap["sonarCheckId"])
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, parseItem(elMap, sonarCheckID, sonarCheckHost))
+	}
+	return values, nil
+}
+
+func parseDNSFailoverItem(elMap map[string]interface{}, sonarCheckID int, sonarCheckHost string) *DNSFailoverItemValue {
+	if sonarCheckHost == "" {
+		sonarCheckHost, _ = elMap["value"].(string)
+	}
+	enabled, _ := elMap["enabled"].(bool)
+	return &DNSFailoverItemValue{
+		Enabled:      enabled,
+		Order:        toInt(elMap["order"]),
+		Value:        sonarCheckHost,
+		SonarCheckID: sonarCheckID,
+	}
+}
+
+// parseDNSTXTFailoverItem ignores the host of the Sonar check, it can't be used
+// as the value of TXT record
+func parseDNSTXTFailoverItem(elMap map[string]interface{}, sonarCheckID int, _ string) *DNSFailoverItemValue {
+	return parseDNSFailoverItem(elMap, sonarCheckID, "")
+}
+
+func parseDNSMXFailoverItem(elMap map[string]interface{}, sonarCheckID int, sonarCheckHost string) *DNSMXFailoverItemValue {
+	if sonarCheckHost == "" {
+		sonarCheckHost, _ = elMap["server"].(string)
+	}
+	enabled, _ := elMap["enabled"].(bool)
+	return &DNSMXFailoverItemValue{
+		Enabled:      enabled,
+		Order:        toInt(elMap["order"]),
+		SonarCheckID: sonarCheckID,
+		Server:       sonarCheckHost,
+		Priority:     toInt(elMap["priority"]),
+	}
+}
+
 func getSonarCheckID(i interface{}) (int, string, error) {
 	switch v := i.(type) {
 	case string:
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestDNSDomain_UnmarshalJSON(t *testing.T) {
+	data := `{"id":1004580,"name":"example.com","note":"","status":"ACTIVE","geoip":false,"gtd":true,"nameservers":["ns11.constellix.com"],"tags":[],"template":null,"vanityNameserver":{"id":12,"name":"vanity"},"contacts":[],"soa":{"primaryNameserver":"ns11.constellix.com.","email":"dns.constellix.com.","ttl":86400,"serial":2015010102,"refresh":86400,"retry":7200,"expire":3600000,"negativeCache":180}}`
+	var obj DNSDomain
+	err := json.Unmarshal([]byte(data), &obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.VanityNameserver != 12 {
+		t.Errorf("expected vanity nameserver %d, got %v", 12, obj.VanityNameserver)
+	}
+	if obj.SOA == nil || obj.SOA.NegativeCache != 180 {
+		t.Errorf("unexpected SOA %v", obj.SOA)
+	}
+}
+
+func TestExpectedDNSDomain_Compare(t *testing.T) {
+	active := &DNSDomain{
+		ID:         1,
+		Name:       "example.com",
+		Note:       "old",
+		GTDEnabled: true,
+		SOA: &DNSDomainSOA{
+			PrimaryNameserver: "ns11.constellix.com.",
+			Email:             "dns.constellix.com.",
+			TTL:               86400,
+			Refresh:           86400,
+			Retry:             7200,
+			Expire:            3600000,
+			NegativeCache:     180,
+		},
+	}
+	data := `
+name: example.com
+gtd: true
+soa:
+  primaryNameserver: ns11.constellix.com.
+  email: dns.constellix.com.
+  ttl: 86400
+  refresh: 86400
+  retry: 7200
+  expire: 3600000
+  negativeCache: 300
+`
+	var expected ExpectedDNSDomain
+	err := yaml.Unmarshal([]byte(data), &expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = expected.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	action, diffs, err := Compare(&expected, active)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if action != ActionUpate {
+		t.Errorf("expected action %q, got %q", ActionUpate, action)
+	}
+	// Note is not defined in configuration and is not compared
+	if len(diffs) != 1 || diffs[0].FieldName != "SOA" {
+		t.Errorf("expected diff in SOA only, got %v", diffs)
+	}
+}
+
+func TestExpectedDNSDomain_Validate(t *testing.T) {
+	var expected ExpectedDNSDomain
+	err := yaml.Unmarshal([]byte(`{name: example.com, soa: {primaryNameserver: "ns 1"}}`), &expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = expected.Validate()
+	want := `example.com: invalid SOA primary nameserver "ns 1", expected a hostname`
+	if err == nil || err.Error() != want {
+		t.Errorf("expected error %q, got %v", want, err)
+	}
+}
+
+func TestExpectedDNSDomain_Validate_partial_SOA(t *testing.T) {
+	var expected ExpectedDNSDomain
+	err := yaml.Unmarshal([]byte(`{name: example.com, soa: {email: dns.example.com., ttl: 3600}}`), &expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = expected.Validate()
+	want := "example.com: SOA fields primaryNameserver, refresh, retry, expire, negativeCache are not defined, SOA is updated as a whole"
+	if err == nil || err.Error() != want {
+		t.Errorf("expected error %q, got %v", want, err)
+	}
+}
+
+func TestFilterConfiguredDNSDomains(t *testing.T) {
+	active := []*DNSDomain{{ID: 1, Name: "example.com"}, {ID: 2, Name: "example.org"}}
+	expected := []*ExpectedDNSDomain{{DNSDomain: DNSDomain{Name: "example.org"}}}
+	filtered := filterConfiguredDNSDomains(active, expected)
+	if len(filtered) != 1 || filtered[0].ID != 2 {
+		t.Errorf("expected only example.org, got %v", filtered)
+	}
+}
@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// zoneAnswer is a set of resource records rendered for a DNS record
+type zoneAnswer struct {
+	// Explanation of how the answer was derived, rendered as a comment
+	comment string
+	data    []string
+	// Answer is rendered commented out
+	disabled bool
+}
+
+// dnsPoolKey identifies the pool, IDs of pools are unique only within the type
+type dnsPoolKey struct {
+	Type string
+	ID   int
+}
+
+// exportZoneFile renders the domain and its records as RFC 1035 zone file.
+// Zone files have no notion of failover, pools or geo routing, so for such
+// records a commented "default" answer is rendered
+func exportZoneFile(w io.Writer, domain *DNSDomain, records []*DNSRecord, pools []*Pool) error {
+	poolsByID := make(map[dnsPoolKey]*Pool)
+	for _, pool := range pools {
+		poolsByID[dnsPoolKey{pool.Type, pool.ID}] = pool
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "; Zone %s exported from Constellix\n", domain.Name)
+	fmt.Fprintf(&sb, "$ORIGIN %s\n", toFQDN(domain.Name, "."))
+	if domain.SOA != nil {
+		soa := domain.SOA
+		sb.WriteString("; Serial is managed by Constellix, 1 is a placeholder\n")
+		fmt.Fprintf(&sb, "@\t%d\tIN\tSOA\t%s %s 1 %d %d %d %d\n",
+			soa.TTL, soa.PrimaryNameserver, soa.Email, soa.Refresh, soa.Retry, soa.Expire, soa.NegativeCache)
+	}
+
+	// Apex NS records are managed by Constellix and are usually not returned
+	// as records
+	hasApexNS := false
+	for _, record := range records {
+		if record.Name == "" && record.Type == "NS" {
+			hasApexNS = true
+		}
+	}
+	if !hasApexNS && len(domain.Nameservers) > 0 {
+		sb.WriteString("; Nameservers of the domain\n")
+		for _, ns := range domain.Nameservers {
+			fmt.Fprintf(&sb, "@\t86400\tIN\tNS\t%s\n", toFQDN(ns, "."))
+		}
+	}
+
+	sorted := make([]*DNSRecord, len(records))
+	copy(sorted, records)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Name == sorted[j].Name {
+			return sorted[i].Type < sorted[j].Type
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	// Records with the same name and type differ by region, geoproximity or IP
+	// filter. Only the default one is answered by the zone file
+	type nameType struct {
+		name       string
+		recordType string
+	}
+	groups := make(map[nameType][]*DNSRecord)
+	var keys []nameType
+	for _, record := range sorted {
+		key := nameType{record.Name, record.Type}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], record)
+	}
+
+	for _, key := range keys {
+		group := groups[key]
+		defaultFound := false
+		for _, record := range group {
+			if isDefaultDNSRecordVariant(record) {
+				defaultFound = true
+			}
+		}
+		for i, record := range group {
+			answer := dnsRecordZoneAnswer(record, poolsByID)
+			if !isDefaultDNSRecordVariant(record) {
+				variant := fmt.Sprintf("region %s", record.Region)
+				if record.GeoProximity != nil {
+					variant += fmt.Sprintf(", geoproximity %v", record.GeoProximity)
+				}
+				if record.IPFilter != nil {
+					variant += fmt.Sprintf(", ipfilter %v", record.IPFilter)
+				}
+				if !defaultFound && i == 0 {
+					answer.comment = joinZoneComments(variant+": used as default answer, no default variant exists", answer.comment)
+				} else {
+					answer.comment = joinZoneComments(variant+": not answered by the zone file", answer.comment)
+					answer.disabled = true
+				}
+			}
+			if !record.Enabled {
+				answer.comment = joinZoneComments("record is disabled", answer.comment)
+				answer.disabled = true
+			}
+
+			sb.WriteString("\n")
+			if answer.comment != "" {
+				fmt.Fprintf(&sb, "; %s %q: %s\n", record.Type, record.Name, answer.comment)
+			}
+			name := record.Name
+			if name == "" {
+				name = "@"
+			}
+			for _, data := range answer.data {
+				if answer.disabled {
+					sb.WriteString("; ")
+				}
+				fmt.Fprintf(&sb, "%s\t%d\tIN\t%s\t%s\n", name, record.TTL, record.Type, data)
+			}
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// isDefaultDNSRecordVariant returns true if the record is answered to all
+// clients
+func isDefaultDNSRecordVariant(record *DNSRecord) bool {
+	return (record.Region == "" || record.Region == "default") && record.GeoProximity == nil && record.IPFilter == nil
+}
+
+func joinZoneComments(comments ...string) string {
+	var nonEmpty []string
+	for _, comment := range comments {
+		if comment != "" {
+			nonEmpty = append(nonEmpty, comment)
+		}
+	}
+	return strings.Join(nonEmpty, "; ")
+}
+
+// dnsRecordZoneAnswer renders the value of the record as data of resource
+// records
+func dnsRecordZoneAnswer(record *DNSRecord, pools map[dnsPoolKey]*Pool) *zoneAnswer {
+	if record.IsUnmanaged() {
+		return &zoneAnswer{comment: "unsupported record type, not exported"}
+	}
+	switch record.Type {
+	case "ANAME", "HTTP":
+		// Constellix specific types, they can't be represented in zone files
+		answer := &zoneAnswer{disabled: true}
+		if record.Type == "ANAME" {
+			answer.data = dnsRecordZoneValues(record, pools, answer)
+		}
+		answer.comment = joinZoneComments("record type is specific to Constellix, not answered by the zone file", answer.comment)
+		return answer
+	}
+	answer := &zoneAnswer{}
+	answer.data = dnsRecordZoneValues(record, pools, answer)
+	if len(answer.data) == 0 {
+		answer.comment = joinZoneComments(answer.comment, "no enabled values")
+	}
+	return answer
+}
+
+// dnsRecordZoneValues returns enabled values of the record, the comment of
+// the answer is updated for modes other than standard
+func dnsRecordZoneValues(record *DNSRecord, pools map[dnsPoolKey]*Pool, answer *zoneAnswer) []string {
+	var data []string
+	switch v := record.Value.(type) {
+	case []*DNSStandardItemValue:
+		for _, item := range v {
+			if item.Enabled {
+				data = append(data, formatZoneValue(record.Type, item.Value))
+			}
+		}
+	case []*DNSMXStandardItemValue:
+		for _, item := range v {
+			if item.Enabled {
+				data = append(data, fmt.Sprintf("%d %s", item.Priority, item.Server))
+			}
+		}
+	case []*DNSSRVStandardItemValue:
+		for _, item := range v {
+			if item.Enabled {
+				data = append(data, fmt.Sprintf("%d %d %d %s", item.Priority, item.Weight, item.Port, item.Host))
+			}
+		}
+	case []*DNSCAAStandardItemValue:
+		for _, item := range v {
+			if item.Enabled {
+				data = append(data, fmt.Sprintf("%d %s %s", item.Flags, item.Tag, quoteZoneString(item.Data)))
+			}
+		}
+	case []*DNSHINFOStandardItemValue:
+		for _, item := range v {
+			if item.Enabled {
+				data = append(data, fmt.Sprintf("%s %s", quoteZoneString(item.CPU), quoteZoneString(item.OS)))
+			}
+		}
+	case []*DNSRPStandardItemValue:
+		for _, item := range v {
+			if item.Enabled {
+				data = append(data, fmt.Sprintf("%s %s", item.Mailbox, item.TXT))
+			}
+		}
+	case []*DNSCERTStandardItemValue:
+		for _, item := range v {
+			if item.Enabled {
+				data = append(data, fmt.Sprintf("%d %d %d %s", item.CertificateType, item.KeyTag, item.Algorithm, item.Certificate))
+			}
+		}
+	case []*DNSNAPTRStandardItemValue:
+		for _, item := range v {
+			if item.Enabled {
+				data = append(data, fmt.Sprintf("%d %d %s %s %s %s", item.Order, item.Preference,
+					quoteZoneString(item.Flags), quoteZoneString(item.Service), quoteZoneString(item.Regexp), item.Replacement))
+			}
+		}
+	case *DNSFailoverValue:
+		answer.comment = "failover mode, default answer is the enabled value with the lowest order, Sonar checks are not exported"
+		var first *DNSFailoverItemValue
+		for _, item := range v.Values {
+			if item.Enabled && (first == nil || item.Order < first.Order) {
+				first = item
+			}
+		}
+		if first != nil {
+			data = append(data, formatZoneValue(record.Type, first.Value))
+		}
+	case *DNSMXFailoverValue:
+		answer.comment = "failover mode, default answer is the enabled value with the lowest order, Sonar checks are not exported"
+		var first *DNSMXFailoverItemValue
+		for _, item := range v.Values {
+			if item.Enabled && (first == nil || item.Order < first.Order) {
+				first = item
+			}
+		}
+		if first != nil {
+			data = append(data, fmt.Sprintf("%d %s", first.Priority, first.Server))
+		}
+	case []*DNSFailoverItemValue:
+		answer.comment = "roundrobin-failover mode, default answer is all enabled values, Sonar checks are not exported"
+		for _, item := range v {
+			if item.Enabled {
+				data = append(data, formatZoneValue(record.Type, item.Value))
+			}
+		}
+	case []*DNSMXFailoverItemValue:
+		answer.comment = "roundrobin-failover mode, default answer is all enabled values, Sonar checks are not exported"
+		for _, item := range v {
+			if item.Enabled {
+				data = append(data, fmt.Sprintf("%d %s", item.Priority, item.Server))
+			}
+		}
+	case []int:
+		// Pools mode
+		var names []string
+		for _, poolID := range v {
+			pool, ok := pools[dnsPoolKey{record.Type, poolID}]
+			if !ok {
+				names = append(names, fmt.Sprintf("%d (not found)", poolID))
+				continue
+			}
+			names = append(names, pool.Name)
+			for _, item := range pool.Values {
+				if item.Enabled {
+					data = append(data, formatZoneValue(record.Type, item.Value))
+				}
+			}
+		}
+		answer.comment = fmt.Sprintf(
+			"pools mode, default answer is all enabled members of pools %s, weights and Sonar checks are not exported",
+			strings.Join(names, ", "),
+		)
+	default:
+		answer.comment = fmt.Sprintf("%s mode is not supported, not exported", record.Mode)
+		answer.disabled = true
+	}
+	return data
+}
+
+// formatZoneValue formats value of single-field records. Values of TXT records
+// are already quoted by Constellix
+func formatZoneValue(recordType, value string) string {
+	if (recordType == "TXT" || recordType == "SPF") && !strings.HasPrefix(value, `"`) {
+		return quoteZoneString(value)
+	}
+	return value
+}
+
+// quoteZoneString returns quoted character string
+func quoteZoneString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestExportZoneFile(t *testing.T) {
+	data := `[
+{"id":1,"name":"www","type":"A","ttl":300,"mode":"standard","region":"default","enabled":true,"value":[{"value":"192.0.2.1","enabled":true},{"value":"192.0.2.9","enabled":false}]},
+{"id":2,"name":"www","type":"A","ttl":300,"mode":"standard","region":"europe","enabled":true,"value":[{"value":"192.0.2.2","enabled":true}]},
+{"id":3,"name":"api","type":"CNAME","ttl":60,"mode":"failover","region":"default","enabled":true,"value":{"enabled":true,"mode":"normal","values":[{"value":"backup.example.net.","order":2,"sonarCheckId":null,"enabled":true},{"value":"primary.example.net.","order":1,"sonarCheckId":null,"enabled":true}]}},
+{"id":4,"name":"web","type":"A","ttl":60,"mode":"pools","region":"default","enabled":true,"value":[7]},
+{"id":5,"name":"","type":"TXT","ttl":3600,"mode":"standard","region":"default","enabled":true,"value":[{"value":"\"v=spf1 -all\"","enabled":true}]},
+{"id":6,"name":"sip","type":"NAPTR","ttl":3600,"mode":"standard","region":"default","enabled":true,"value":[{"order":100,"preference":10,"flags":"u","service":"E2U+sip","regularExpression":"!^\\+1(.*)$!sip:\\1@example.com!","replacement":".","enabled":true}]},
+{"id":7,"name":"old","type":"MX","ttl":3600,"mode":"standard","region":"default","enabled":false,"value":[{"server":"mail.example.com.","priority":10,"enabled":true}]},
+{"id":8,"name":"key","type":"SSHFP","ttl":3600,"mode":"standard","region":"default","enabled":true,"value":[{"algorithm":1}]}
+]`
+	var records []*DNSRecord
+	err := json.Unmarshal([]byte(data), &records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	domain := &DNSDomain{
+		Name:        "example.com",
+		Nameservers: []string{"ns11.constellix.com", "ns21.constellix.com"},
+		SOA: &DNSDomainSOA{
+			PrimaryNameserver: "ns11.constellix.com.",
+			Email:             "dns.constellix.com.",
+			TTL:               86400,
+			Refresh:           86400,
+			Retry:             7200,
+			Expire:            3600000,
+			NegativeCache:     180,
+		},
+	}
+	pools := []*Pool{
+		{ID: 7, Name: "web-eu", Type: "A", Values: []*PoolValue{
+			{Value: "192.0.2.10", Enabled: true},
+			{Value: "192.0.2.11", Enabled: false},
+		}},
+		// Pool of another type with the same ID
+		{ID: 7, Name: "web-cdn", Type: "CNAME", Values: []*PoolValue{
+			{Value: "cdn.example.net.", Enabled: true},
+		}},
+	}
+
+	var sb strings.Builder
+	err = exportZoneFile(&sb, domain, records, pools)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `; Zone example.com exported from Constellix
+$ORIGIN example.com.
+; Serial is managed by Constellix, 1 is a placeholder
+@	86400	IN	SOA	ns11.constellix.com. dns.constellix.com. 1 86400 7200 3600000 180
+; Nameservers of the domain
+@	86400	IN	NS	ns11.constellix.com.
+@	86400	IN	NS	ns21.constellix.com.
+
+@	3600	IN	TXT	"v=spf1 -all"
+
+; CNAME "api": failover mode, default answer is the enabled value with the lowest order, Sonar checks are not exported
+api	60	IN	CNAME	primary.example.net.
+
+; SSHFP "key": unsupported record type, not exported
+
+; MX "old": record is disabled
+; old	3600	IN	MX	10 mail.example.com.
+
+sip	3600	IN	NAPTR	100 10 "u" "E2U+sip" "!^\\+1(.*)$!sip:\\1@example.com!" .
+
+; A "web": pools mode, default answer is all enabled members of pools web-eu, weights and Sonar checks are not exported
+web	60	IN	A	192.0.2.10
+
+www	300	IN	A	192.0.2.1
+
+; A "www": region europe: not answered by the zone file
+; www	300	IN	A	192.0.2.2
+`
+	if sb.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sb.String())
+	}
+
+	// Exported zone file can be imported back
+	zoneRecords, err := parseZoneFile(strings.NewReader(sb.String()), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, problems := importZoneRecords(zoneRecords, "example.com")
+	// SOA and nameservers of the domain are managed by Constellix
+	if len(problems) != 3 || problems[0].Type != "SOA" || problems[1].Type != "NS" || problems[2].Type != "NS" {
+		t.Errorf("expected only SOA and NS problems, got %d problems", len(problems))
+	}
+	if len(imported) != 5 {
+		t.Fatalf("expected 5 records, got %d", len(imported))
+	}
+	naptr := imported[2].Value.([]*DNSNAPTRStandardItemValue)
+	if naptr[0].Regexp != `!^\+1(.*)$!sip:\1@example.com!` {
+		t.Errorf("unexpected NAPTR regexp %s", naptr[0].Regexp)
+	}
+}
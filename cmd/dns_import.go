@@ -0,0 +1,333 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// importedDNSRecord is a record converted from an external source. It contains
+// only the fields which are needed to define ExpectedDNSRecord
+type importedDNSRecord struct {
+	Name   string      `yaml:"name"`
+	Type   string      `yaml:"type"`
+	TTL    int         `yaml:"ttl"`
+	Mode   string      `yaml:"mode"`
+	Region string      `yaml:"region"`
+	Value  interface{} `yaml:"value"`
+}
+
+// importProblem describes an entry of the external source which can't be
+// represented in the configuration or was changed during the import
+type importProblem struct {
+	Source string
+	Name   string
+	Type   string
+	Reason string
+}
+
+// reportImportProblems prints entries which require attention after import
+func reportImportProblems(problems []*importProblem) {
+	if len(problems) == 0 {
+		return
+	}
+	report := table.NewWriter()
+	if reportToTestBuffer {
+		// Skip header in tests
+		report.SetOutputMirror(testBuffer)
+	} else {
+		report.SetOutputMirror(os.Stdout)
+		report.SetTitle("Entries which require attention")
+		report.AppendHeader(table.Row{"Source", "Name", "Type", "Reason"})
+	}
+	for _, problem := range problems {
+		report.AppendRow(table.Row{problem.Source, problem.Name, problem.Type, problem.Reason})
+	}
+	printReport(report)
+}
+
+// Certificate types of CERT records, RFC 4398
+var certTypeMnemonics = map[string]int{
+	"PKIX": 1, "SPKI": 2, "PGP": 3, "IPKIX": 4, "ISPKI": 5, "IPGP": 6, "ACPKIX": 7, "IACPKIX": 8, "URI": 253, "OID": 254,
+}
+
+// importZoneRecords converts records of the zone file into records of the
+// domain. Resource records with the same name and type are grouped into a
+// single record in standard mode
+func importZoneRecords(zoneRecords []*zoneRecord, domainName string) ([]*importedDNSRecord, []*importProblem) {
+	var records []*importedDNSRecord
+	var problems []*importProblem
+	grouped := make(map[string]*importedDNSRecord)
+	domainFQDN := toFQDN(domainName, ".")
+
+	for _, rr := range zoneRecords {
+		source := fmt.Sprintf("line %d", rr.Line)
+		name, ok := relativeZoneName(rr.Name, domainFQDN)
+		if !ok {
+			problems = append(problems, &importProblem{source, rr.Name, rr.Type, "name is outside of the domain " + domainName})
+			continue
+		}
+		addProblem := func(reason string) {
+			problems = append(problems, &importProblem{source, name, rr.Type, reason})
+		}
+		if rr.Class != "IN" {
+			addProblem("unsupported class " + rr.Class)
+			continue
+		}
+		if rr.Type == "SOA" {
+			addProblem("SOA record is managed with domain settings")
+			continue
+		}
+		if rr.Type == "NS" && name == "" {
+			addProblem("apex NS is managed by Constellix")
+			continue
+		}
+		value, err := zoneRecordValue(rr)
+		if err != nil {
+			addProblem(err.Error())
+			continue
+		}
+
+		key := name + " " + rr.Type
+		record, ok := grouped[key]
+		if !ok {
+			record = &importedDNSRecord{Name: name, Type: rr.Type, TTL: rr.TTL, Mode: "standard", Region: "default"}
+			grouped[key] = record
+			records = append(records, record)
+		}
+		if rr.TTL != record.TTL {
+			// All resource records of the set should have the same TTL, RFC 2181
+			if rr.TTL < record.TTL {
+				record.TTL = rr.TTL
+			}
+			addProblem(fmt.Sprintf("TTL differs within the record set, using %d", record.TTL))
+		}
+		record.Value = appendZoneRecordValue(record.Value, value)
+	}
+	return records, problems
+}
+
+// appendZoneRecordValue appends the item to the list of values of the same type
+func appendZoneRecordValue(values interface{}, item interface{}) interface{} {
+	switch v := item.(type) {
+	case *DNSStandardItemValue:
+		list, _ := values.([]*DNSStandardItemValue)
+		return append(list, v)
+	case *DNSMXStandardItemValue:
+		list, _ := values.([]*DNSMXStandardItemValue)
+		return append(list, v)
+	case *DNSSRVStandardItemValue:
+		list, _ := values.([]*DNSSRVStandardItemValue)
+		return append(list, v)
+	case *DNSCAAStandardItemValue:
+		list, _ := values.([]*DNSCAAStandardItemValue)
+		return append(list, v)
+	case *DNSHINFOStandardItemValue:
+		list, _ := values.([]*DNSHINFOStandardItemValue)
+		return append(list, v)
+	case *DNSRPStandardItemValue:
+		list, _ := values.([]*DNSRPStandardItemValue)
+		return append(list, v)
+	case *DNSCERTStandardItemValue:
+		list, _ := values.([]*DNSCERTStandardItemValue)
+		return append(list, v)
+	case *DNSNAPTRStandardItemValue:
+		list, _ := values.([]*DNSNAPTRStandardItemValue)
+		return append(list, v)
+	}
+	return values
+}
+
+// zoneRecordValue converts the data of the resource record into the value of
+// standard mode
+func zoneRecordValue(rr *zoneRecord) (interface{}, error) {
+	data := rr.Data
+	expectFields := func(n int) error {
+		if len(data) != n {
+			return fmt.Errorf("expected %d fields in record data, got %d", n, len(data))
+		}
+		return nil
+	}
+	switch rr.Type {
+	case "A", "AAAA":
+		if err := expectFields(1); err != nil {
+			return nil, err
+		}
+		ip := net.ParseIP(data[0].Text)
+		if ip == nil || (rr.Type == "A") != (ip.To4() != nil) {
+			return nil, fmt.Errorf("invalid address %q", data[0].Text)
+		}
+		return &DNSStandardItemValue{Value: data[0].Text, Enabled: true}, nil
+	case "CNAME", "ANAME", "NS", "PTR":
+		if err := expectFields(1); err != nil {
+			return nil, err
+		}
+		return &DNSStandardItemValue{Value: toFQDN(data[0].Text, rr.Origin), Enabled: true}, nil
+	case "TXT", "SPF":
+		if len(data) == 0 {
+			return nil, fmt.Errorf("expected at least 1 field in record data, got 0")
+		}
+		// Constellix keeps character strings quoted
+		strs := make([]string, len(data))
+		for i, token := range data {
+			strs[i] = `"` + token.Text + `"`
+		}
+		return &DNSStandardItemValue{Value: strings.Join(strs, " "), Enabled: true}, nil
+	case "MX":
+		if err := expectFields(2); err != nil {
+			return nil, err
+		}
+		priority, err := strconv.Atoi(data[0].Text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid priority %q", data[0].Text)
+		}
+		return &DNSMXStandardItemValue{Server: toFQDN(data[1].Text, rr.Origin), Priority: priority, Enabled: true}, nil
+	case "SRV":
+		if err := expectFields(4); err != nil {
+			return nil, err
+		}
+		numbers, err := parseZoneNumbers(data[:3])
+		if err != nil {
+			return nil, err
+		}
+		return &DNSSRVStandardItemValue{
+			Priority: numbers[0],
+			Weight:   numbers[1],
+			Port:     numbers[2],
+			Host:     toFQDN(data[3].Text, rr.Origin),
+			Enabled:  true,
+		}, nil
+	case "CAA":
+		if err := expectFields(3); err != nil {
+			return nil, err
+		}
+		flags, err := strconv.Atoi(data[0].Text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid flags %q", data[0].Text)
+		}
+		return &DNSCAAStandardItemValue{Flags: flags, Tag: data[1].Text, Data: unescapeZoneString(data[2].Text), Enabled: true}, nil
+	case "HINFO":
+		if err := expectFields(2); err != nil {
+			return nil, err
+		}
+		return &DNSHINFOStandardItemValue{
+			CPU:     unescapeZoneString(data[0].Text),
+			OS:      unescapeZoneString(data[1].Text),
+			Enabled: true,
+		}, nil
+	case "RP":
+		if err := expectFields(2); err != nil {
+			return nil, err
+		}
+		return &DNSRPStandardItemValue{
+			Mailbox: toFQDN(data[0].Text, rr.Origin),
+			TXT:     toFQDN(data[1].Text, rr.Origin),
+			Enabled: true,
+		}, nil
+	case "CERT":
+		if len(data) < 4 {
+			return nil, fmt.Errorf("expected at least 4 fields in record data, got %d", len(data))
+		}
+		certType, ok := certTypeMnemonics[strings.ToUpper(data[0].Text)]
+		if !ok {
+			var err error
+			certType, err = strconv.Atoi(data[0].Text)
+			if err != nil {
+				return nil, fmt.Errorf("invalid certificate type %q", data[0].Text)
+			}
+		}
+		numbers, err := parseZoneNumbers(data[1:3])
+		if err != nil {
+			return nil, err
+		}
+		// Base64 data can be split into several fields
+		var certificate string
+		for _, token := range data[3:] {
+			certificate += token.Text
+		}
+		return &DNSCERTStandardItemValue{
+			CertificateType: certType,
+			KeyTag:          numbers[0],
+			Algorithm:       numbers[1],
+			Certificate:     certificate,
+			Enabled:         true,
+		}, nil
+	case "NAPTR":
+		if err := expectFields(6); err != nil {
+			return nil, err
+		}
+		numbers, err := parseZoneNumbers(data[:2])
+		if err != nil {
+			return nil, err
+		}
+		value := &DNSNAPTRStandardItemValue{
+			Order:       numbers[0],
+			Preference:  numbers[1],
+			Flags:       unescapeZoneString(data[2].Text),
+			Service:     unescapeZoneString(data[3].Text),
+			Regexp:      unescapeZoneString(data[4].Text),
+			Replacement: data[5].Text,
+			Enabled:     true,
+		}
+		if value.Replacement != "." {
+			value.Replacement = toFQDN(value.Replacement, rr.Origin)
+		}
+		return value, value.Validate()
+	}
+	return nil, fmt.Errorf("unsupported record type")
+}
+
+// unescapeZoneString removes escaping of the character string, `\X` is
+// replaced with X and `\DDD` with the byte of the decimal value
+func unescapeZoneString(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		if i+3 < len(s) {
+			if n, err := strconv.Atoi(s[i+1 : i+4]); err == nil && n < 256 {
+				sb.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		sb.WriteByte(s[i+1])
+		i++
+	}
+	return sb.String()
+}
+
+// parseZoneNumbers parses numeric fields of the record data
+func parseZoneNumbers(tokens []zoneToken) ([]int, error) {
+	numbers := make([]int, len(tokens))
+	for i, token := range tokens {
+		n, err := strconv.Atoi(token.Text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", token.Text)
+		}
+		numbers[i] = n
+	}
+	return numbers, nil
+}
+
+// relativeZoneName returns the name relative to the domain, empty string for
+// the apex. False is returned if the name is outside of the domain
+func relativeZoneName(name, domainFQDN string) (string, bool) {
+	if strings.EqualFold(name, domainFQDN) {
+		return "", true
+	}
+	suffix := "." + domainFQDN
+	if len(name) > len(suffix) && strings.EqualFold(name[len(name)-len(suffix):], suffix) {
+		return name[:len(name)-len(suffix)], true
+	}
+	return "", false
+}
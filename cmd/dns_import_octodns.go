@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Default TTL of octoDNS records
+const octodnsDefaultTTL = 3600
+
+// GTD regions of octoDNS geo codes. Only continents are mapped, countries and
+// provinces have no Constellix region
+var octodnsGeoRegions = map[string]string{
+	"EU": "europe",
+	"AS": "asia-pacific",
+	"OC": "oceania",
+	"SA": "south-america",
+}
+
+// octodnsRecord is a record of octoDNS zone file
+type octodnsRecord struct {
+	Type    string                 `yaml:"type"`
+	TTL     *int                   `yaml:"ttl"`
+	Value   yaml.Node              `yaml:"value"`
+	Values  yaml.Node              `yaml:"values"`
+	Geo     map[string][]string    `yaml:"geo"`
+	Dynamic interface{}            `yaml:"dynamic"`
+	Octodns map[string]interface{} `yaml:"octodns"`
+}
+
+// octodnsMXValue supports both current (exchange, preference) and legacy
+// (value, priority) fields
+type octodnsMXValue struct {
+	Exchange   string `yaml:"exchange"`
+	Preference *int   `yaml:"preference"`
+	Value      string `yaml:"value"`
+	Priority   *int   `yaml:"priority"`
+}
+
+type octodnsSRVValue struct {
+	Priority int    `yaml:"priority"`
+	Weight   int    `yaml:"weight"`
+	Port     int    `yaml:"port"`
+	Target   string `yaml:"target"`
+}
+
+type octodnsCAAValue struct {
+	Flags int    `yaml:"flags"`
+	Tag   string `yaml:"tag"`
+	Value string `yaml:"value"`
+}
+
+type octodnsNAPTRValue struct {
+	Order       int    `yaml:"order"`
+	Preference  int    `yaml:"preference"`
+	Flags       string `yaml:"flags"`
+	Service     string `yaml:"service"`
+	Regexp      string `yaml:"regexp"`
+	Replacement string `yaml:"replacement"`
+}
+
+// importOctodnsRecords converts records of octoDNS zone file into records of
+// the domain. Geo blocks are converted into records of GTD regions when the
+// code is a continent which has a Constellix region
+func importOctodnsRecords(data []byte, fileName string) ([]*importedDNSRecord, []*importProblem, error) {
+	var document yaml.Node
+	err := yaml.Unmarshal(data, &document)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(document.Content) == 0 {
+		return nil, nil, nil
+	}
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("expected a mapping of record names")
+	}
+
+	var records []*importedDNSRecord
+	var problems []*importProblem
+	for i := 0; i < len(root.Content); i += 2 {
+		name := root.Content[i].Value
+		nodes := []*yaml.Node{root.Content[i+1]}
+		if root.Content[i+1].Kind == yaml.SequenceNode {
+			nodes = root.Content[i+1].Content
+		}
+		for _, node := range nodes {
+			source := fmt.Sprintf("%s:%d", fileName, node.Line)
+			var or octodnsRecord
+			err = node.Decode(&or)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %s", source, err)
+			}
+			recordType := strings.ToUpper(or.Type)
+			addProblem := func(reason string) {
+				problems = append(problems, &importProblem{source, name, recordType, reason})
+			}
+			if ignored, _ := or.Octodns["ignored"].(bool); ignored {
+				addProblem("record is ignored by octoDNS, skipped")
+				continue
+			}
+			if recordType == "NS" && name == "" {
+				addProblem("apex NS is managed by Constellix")
+				continue
+			}
+			if len(or.Octodns) > 0 {
+				keys := make([]string, 0, len(or.Octodns))
+				for k := range or.Octodns {
+					keys = append(keys, k)
+				}
+				sort.Strings(keys)
+				addProblem(fmt.Sprintf("octodns settings %s are not supported, ignored", strings.Join(keys, ", ")))
+			}
+			if or.Dynamic != nil {
+				addProblem("dynamic rules are not supported, only default values are imported")
+			}
+			if recordType == "ALIAS" {
+				recordType = "ANAME"
+			}
+
+			valueNodes := octodnsValueNodes(&or)
+			if len(valueNodes) == 0 {
+				addProblem("record has no values")
+				continue
+			}
+			value, err := octodnsRecordValue(recordType, valueNodes)
+			if err != nil {
+				addProblem(err.Error())
+				continue
+			}
+			ttl := octodnsDefaultTTL
+			if or.TTL != nil {
+				ttl = *or.TTL
+			}
+			records = append(records, &importedDNSRecord{
+				Name: name, Type: recordType, TTL: ttl, Mode: "standard", Region: "default", Value: value,
+			})
+
+			codes := make([]string, 0, len(or.Geo))
+			for code := range or.Geo {
+				codes = append(codes, code)
+			}
+			sort.Strings(codes)
+			for _, code := range codes {
+				region, ok := octodnsGeoRegions[code]
+				if !ok {
+					addProblem(fmt.Sprintf("geo code %s has no matching GTD region, skipped", code))
+					continue
+				}
+				geoNodes := make([]*yaml.Node, len(or.Geo[code]))
+				for j, v := range or.Geo[code] {
+					geoNodes[j] = &yaml.Node{Kind: yaml.ScalarNode, Value: v}
+				}
+				geoValue, err := octodnsRecordValue(recordType, geoNodes)
+				if err != nil {
+					addProblem(fmt.Sprintf("geo code %s: %s", code, err))
+					continue
+				}
+				records = append(records, &importedDNSRecord{
+					Name: name, Type: recordType, TTL: ttl, Mode: "standard", Region: region, Value: geoValue,
+				})
+			}
+		}
+	}
+	return records, problems, nil
+}
+
+// octodnsValueNodes returns values of the record, octoDNS accepts both `value`
+// and `values`
+func octodnsValueNodes(or *octodnsRecord) []*yaml.Node {
+	for _, node := range []*yaml.Node{&or.Values, &or.Value} {
+		switch node.Kind {
+		case yaml.SequenceNode:
+			return node.Content
+		case yaml.ScalarNode, yaml.MappingNode:
+			return []*yaml.Node{node}
+		}
+	}
+	return nil
+}
+
+// octodnsRecordValue converts octoDNS values into the value of standard mode
+func octodnsRecordValue(recordType string, nodes []*yaml.Node) (interface{}, error) {
+	var values interface{}
+	for _, node := range nodes {
+		var item interface{}
+		switch recordType {
+		case "A", "AAAA":
+			var s string
+			if err := node.Decode(&s); err != nil {
+				return nil, err
+			}
+			ip := net.ParseIP(s)
+			if ip == nil || (recordType == "A") != (ip.To4() != nil) {
+				return nil, fmt.Errorf("invalid address %q", s)
+			}
+			item = &DNSStandardItemValue{Value: s, Enabled: true}
+		case "ANAME", "CNAME", "NS", "PTR":
+			var s string
+			if err := node.Decode(&s); err != nil {
+				return nil, err
+			}
+			item = &DNSStandardItemValue{Value: s, Enabled: true}
+		case "TXT", "SPF":
+			var s string
+			if err := node.Decode(&s); err != nil {
+				return nil, err
+			}
+			item = &DNSStandardItemValue{Value: octodnsTXTValue(s), Enabled: true}
+		case "MX":
+			var v octodnsMXValue
+			if err := node.Decode(&v); err != nil {
+				return nil, err
+			}
+			if v.Exchange == "" {
+				v.Exchange = v.Value
+			}
+			if v.Preference == nil {
+				v.Preference = v.Priority
+			}
+			if v.Exchange == "" || v.Preference == nil {
+				return nil, fmt.Errorf("MX value requires exchange and preference")
+			}
+			item = &DNSMXStandardItemValue{Server: v.Exchange, Priority: *v.Preference, Enabled: true}
+		case "SRV":
+			var v octodnsSRVValue
+			if err := node.Decode(&v); err != nil {
+				return nil, err
+			}
+			item = &DNSSRVStandardItemValue{Priority: v.Priority, Weight: v.Weight, Port: v.Port, Host: v.Target, Enabled: true}
+		case "CAA":
+			var v octodnsCAAValue
+			if err := node.Decode(&v); err != nil {
+				return nil, err
+			}
+			item = &DNSCAAStandardItemValue{Flags: v.Flags, Tag: v.Tag, Data: v.Value, Enabled: true}
+		case "NAPTR":
+			var v octodnsNAPTRValue
+			if err := node.Decode(&v); err != nil {
+				return nil, err
+			}
+			naptr := &DNSNAPTRStandardItemValue{
+				Order:       v.Order,
+				Preference:  v.Preference,
+				Flags:       v.Flags,
+				Service:     v.Service,
+				Regexp:      v.Regexp,
+				Replacement: v.Replacement,
+				Enabled:     true,
+			}
+			if err := naptr.Validate(); err != nil {
+				return nil, err
+			}
+			item = naptr
+		default:
+			return nil, fmt.Errorf("unsupported record type")
+		}
+		values = appendZoneRecordValue(values, item)
+	}
+	return values, nil
+}
+
+// octodnsTXTValue converts octoDNS TXT value into quoted character strings.
+// octoDNS escapes semicolons and splits long values automatically
+func octodnsTXTValue(s string) string {
+	s = strings.ReplaceAll(s, `\;`, ";")
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	var chunks []string
+	for len(s) > 255 {
+		cut := 255
+		// Don't split escape sequences
+		for cut > 0 && s[cut-1] == '\\' {
+			cut--
+		}
+		chunks = append(chunks, `"`+s[:cut]+`"`)
+		s = s[cut:]
+	}
+	chunks = append(chunks, `"`+s+`"`)
+	return strings.Join(chunks, " ")
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"testing"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestImportOctodnsRecords(t *testing.T) {
+	data := `---
+'':
+  - type: A
+    ttl: 300
+    values:
+      - 192.0.2.1
+      - 192.0.2.2
+    geo:
+      EU:
+        - 192.0.2.10
+      NA-US-CA:
+        - 192.0.2.20
+  - type: MX
+    values:
+      - exchange: mx1.example.com.
+        preference: 10
+      - value: mx2.example.com.
+        priority: 20
+  - type: CAA
+    value:
+      flags: 0
+      tag: issue
+      value: letsencrypt.org
+  - type: TXT
+    value: v=spf1 include:_spf.example.com -all\; comment
+_sip._tcp:
+  type: SRV
+  value:
+    priority: 10
+    weight: 20
+    port: 5060
+    target: sip.example.com.
+www:
+  type: ALIAS
+  value: lb.example.net.
+  octodns:
+    healthcheck:
+      path: /health
+api:
+  type: CNAME
+  value: api.example.net.
+  dynamic:
+    pools: {}
+legacy:
+  type: A
+  value: 192.0.2.3
+  octodns:
+    ignored: true
+key:
+  type: SSHFP
+  value:
+    algorithm: 1
+`
+	records, problems, err := importOctodnsRecords([]byte(data), "example.com.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reportToTestBuffer = true
+	defer func() {
+		reportToTestBuffer = false
+		testBuffer.Reset()
+	}()
+	reportImportProblems(problems)
+	expectedReport := "example.com.yaml:3,,A,\"geo code NA-US-CA has no matching GTD region\\, skipped\"\n" +
+		"example.com.yaml:34,www,ALIAS,\"octodns settings healthcheck are not supported\\, ignored\"\n" +
+		"example.com.yaml:40,api,CNAME,\"dynamic rules are not supported\\, only default values are imported\"\n" +
+		"example.com.yaml:45,legacy,A,\"record is ignored by octoDNS\\, skipped\"\n" +
+		"example.com.yaml:50,key,SSHFP,unsupported record type\n"
+	if testBuffer.String() != expectedReport {
+		t.Errorf("expected report:\n%s\ngot:\n%s", expectedReport, testBuffer.String())
+	}
+
+	if len(records) != 8 {
+		t.Fatalf("expected 8 records, got %d", len(records))
+	}
+	if records[1].Region != "europe" || records[1].Value.([]*DNSStandardItemValue)[0].Value != "192.0.2.10" {
+		t.Errorf("unexpected geo record %+v", records[1])
+	}
+	mx := records[2].Value.([]*DNSMXStandardItemValue)
+	if records[2].TTL != 3600 || mx[1].Server != "mx2.example.com." || mx[1].Priority != 20 {
+		t.Errorf("unexpected MX record %+v", records[2])
+	}
+	txt := records[4].Value.([]*DNSStandardItemValue)
+	if txt[0].Value != `"v=spf1 include:_spf.example.com -all; comment"` {
+		t.Errorf("unexpected TXT value %s", txt[0].Value)
+	}
+	if records[6].Type != "ANAME" {
+		t.Errorf("expected ALIAS to be converted to ANAME, got %s", records[6].Type)
+	}
+
+	// Imported records must be valid configuration
+	out, err := yaml.Marshal(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected []*ExpectedDNSRecord
+	err = yaml.Unmarshal(out, &expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range expected {
+		if err := record.Validate(); err != nil {
+			t.Errorf("%s: %s", record.GetResourceID(), err)
+		}
+	}
+	// Default values must match active records of the default region
+	if id := expected[0].GetResourceID(); id != `A "" (default, 0)` {
+		t.Errorf("unexpected resource ID %s", id)
+	}
+	if id := expected[1].GetResourceID(); id != `A "" (europe, 0)` {
+		t.Errorf("unexpected resource ID %s", id)
+	}
+}
+
+func TestImportOctodnsRecords_apex_NS(t *testing.T) {
+	data := `---
+'':
+  - type: NS
+    values:
+      - ns1.example.net.
+      - ns2.example.net.
+  - type: A
+    value: 192.0.2.1
+sub:
+  type: NS
+  value: ns1.example.net.
+`
+	records, problems, err := importOctodnsRecords([]byte(data), "example.com.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || problems[0].Source != "example.com.yaml:3" || problems[0].Reason != "apex NS is managed by Constellix" {
+		t.Errorf("expected apex NS problem, got %+v", problems)
+	}
+	if len(records) != 2 || records[0].Type != "A" || records[1].Name != "sub" || records[1].Type != "NS" {
+		t.Errorf("expected A record and delegation, got %+v", records)
+	}
+}
+
+func TestOctodnsTXTValue(t *testing.T) {
+	long := make([]byte, 300)
+	for i := range long {
+		long[i] = 'a'
+	}
+	value := octodnsTXTValue(string(long))
+	expected := `"` + string(long[:255]) + `" "` + string(long[255:]) + `"`
+	if value != expected {
+		t.Errorf("expected %s, got %s", expected, value)
+	}
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestImportZoneRecords(t *testing.T) {
+	zone := `$ORIGIN example.com.
+$TTL 3600
+@	SOA	ns1 hostmaster 1 7200 3600 1209600 3600
+@	NS	ns1.example.net.
+	NS	ns2.example.net.
+@	MX	10 mail
+@	MX	20 mail.example.net.
+www	300	A	192.0.2.1
+www	600	A	192.0.2.2
+txt	TXT	"v=spf1 -all"
+_sip._tcp	SRV	10 60 5060 sip
+@	CAA	0 issue "letsencrypt.org"
+sig	TLSA	3 1 1 abcdef
+other.example.net.	A	192.0.2.3
+bad	A	2001:db8::1
+sub	NS	ns1.example.net.
+`
+	zoneRecords, err := parseZoneFile(strings.NewReader(zone), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, problems := importZoneRecords(zoneRecords, "example.com")
+
+	reportToTestBuffer = true
+	defer func() {
+		reportToTestBuffer = false
+		testBuffer.Reset()
+	}()
+	reportImportProblems(problems)
+	expectedReport := "line 3,,SOA,SOA record is managed with domain settings\n" +
+		"line 4,,NS,apex NS is managed by Constellix\n" +
+		"line 5,,NS,apex NS is managed by Constellix\n" +
+		"line 9,www,A,\"TTL differs within the record set\\, using 300\"\n" +
+		"line 13,sig,TLSA,unsupported record type\n" +
+		"line 14,other.example.net.,A,name is outside of the domain example.com\n" +
+		"line 15,bad,A,\"invalid address \\\"2001:db8::1\\\"\"\n"
+	if testBuffer.String() != expectedReport {
+		t.Errorf("expected report:\n%s\ngot:\n%s", expectedReport, testBuffer.String())
+	}
+
+	if len(records) != 6 {
+		t.Fatalf("expected 6 records, got %d", len(records))
+	}
+	if records[1].Name != "www" || records[1].TTL != 300 || len(records[1].Value.([]*DNSStandardItemValue)) != 2 {
+		t.Errorf("unexpected A record %+v", records[1])
+	}
+	mx := records[0].Value.([]*DNSMXStandardItemValue)
+	if mx[0].Server != "mail.example.com." || mx[1].Server != "mail.example.net." {
+		t.Errorf("unexpected MX values %+v %+v", mx[0], mx[1])
+	}
+	txt := records[2].Value.([]*DNSStandardItemValue)
+	if txt[0].Value != `"v=spf1 -all"` {
+		t.Errorf("expected quoted TXT value, got %s", txt[0].Value)
+	}
+	if records[5].Name != "sub" || records[5].Type != "NS" {
+		t.Errorf("expected delegation to be imported, got %+v", records[5])
+	}
+
+	// Imported records must be valid configuration
+	data, err := yaml.Marshal(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected []*ExpectedDNSRecord
+	err = yaml.Unmarshal(data, &expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range expected {
+		if err := record.Validate(); err != nil {
+			t.Errorf("%s: %s", record.GetResourceID(), err)
+		}
+	}
+	srv := expected[3].Value.([]*DNSSRVStandardItemValue)
+	if expected[3].Name != "_sip._tcp" || srv[0].Host != "sip.example.com." || srv[0].Port != 5060 {
+		t.Errorf("unexpected SRV record %+v", expected[3])
+	}
+	// Imported records must match active records of the default region
+	if id := expected[1].GetResourceID(); id != `A "www" (default, 0)` {
+		t.Errorf("unexpected resource ID %s", id)
+	}
+}
+
+func TestImportZoneRecords_escaped_strings(t *testing.T) {
+	zone := `$ORIGIN example.com.
+@	CAA	0 issue "ca.example.net\; account=\"42\""
+host	HINFO	"Intel\032x86" "Linux\\GNU"
+@	NAPTR	100 10 "U" "E2U+sip" "!^.*$!sip:info@example.com!" .
+`
+	zoneRecords, err := parseZoneFile(strings.NewReader(zone), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, problems := importZoneRecords(zoneRecords, "example.com")
+	if len(problems) != 0 || len(records) != 3 {
+		t.Fatalf("expected 3 records without problems, got %d records and %d problems", len(records), len(problems))
+	}
+	caa := records[0].Value.([]*DNSCAAStandardItemValue)
+	if caa[0].Data != `ca.example.net; account="42"` {
+		t.Errorf("unexpected CAA data %s", caa[0].Data)
+	}
+	hinfo := records[1].Value.([]*DNSHINFOStandardItemValue)
+	if hinfo[0].CPU != "Intel x86" || hinfo[0].OS != `Linux\GNU` {
+		t.Errorf("unexpected HINFO value %+v", hinfo[0])
+	}
+	naptr := records[2].Value.([]*DNSNAPTRStandardItemValue)
+	if naptr[0].Service != "E2U+sip" || naptr[0].Regexp != "!^.*$!sip:info@example.com!" {
+		t.Errorf("unexpected NAPTR value %+v", naptr[0])
+	}
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Interval between queries of nameservers while waiting for propagation
+var dnsPropagationPollInterval = 10 * time.Second
+
+// Timeout of a single DNS query while waiting for propagation
+const dnsPropagationQueryTimeout = 5 * time.Second
+
+// dnsPropagationTarget is the record changed by the sync, nameservers of the
+// domain must answer with the new values
+type dnsPropagationTarget struct {
+	dnsAnswerTarget
+	Nameservers []string
+}
+
+// dnsPropagationTargets returns records which will be created, updated or
+// deleted by the sync. Changed records which can't be verified over DNS are
+// reported and skipped
+func dnsPropagationTargets(
+	domainName string, nameservers []string, expectedRecords []*ExpectedDNSRecord, activeRecords []*DNSRecord, remove bool,
+) ([]*dnsPropagationTarget, error) {
+	activeCollection := toResourceMatcher(activeRecords)
+	expectedCollection := toResourceMatcher(expectedRecords)
+	var targets []*dnsPropagationTarget
+	for _, record := range expectedRecords {
+		var activeResource IActiveResource
+		if matched := getMatchingResource(record, activeCollection); matched != nil {
+			activeResource = matched.(IActiveResource)
+		}
+		action, _, err := Compare(record, activeResource)
+		if err != nil {
+			return nil, err
+		}
+		if action == ActionOK {
+			continue
+		}
+		name := dnsRecordFQDN(record.Name, domainName)
+		reason := dnsVerifySkipReason(&record.DNSRecord)
+		if reason == "" && record.Mode != "standard" {
+			reason = fmt.Sprintf("answer of %s mode depends on Sonar checks", record.Mode)
+		}
+		if reason != "" {
+			logger.Printf("  propagation of %s %s is not checked: %s\n", name, record.Type, reason)
+			continue
+		}
+		targets = append(targets, &dnsPropagationTarget{
+			dnsAnswerTarget: *newDNSAnswerTarget(domainName, record),
+			Nameservers:     nameservers,
+		})
+	}
+	if !remove {
+		return targets, nil
+	}
+	// Deleted records must have no answer
+	for _, record := range activeRecords {
+		if record.IsUnmanaged() || getMatchingResource(record, expectedCollection) != nil {
+			continue
+		}
+		name := dnsRecordFQDN(record.Name, domainName)
+		if reason := dnsVerifySkipReason(record); reason != "" {
+			logger.Printf("  propagation of %s %s is not checked: %s\n", name, record.Type, reason)
+			continue
+		}
+		targets = append(targets, &dnsPropagationTarget{
+			dnsAnswerTarget: dnsAnswerTarget{Name: name, Type: record.Type},
+			Nameservers:     nameservers,
+		})
+	}
+	return targets, nil
+}
+
+// waitDNSPropagation polls nameservers until every target is answered with
+// the new values by all nameservers of its domain. Progress of a record is
+// printed when the number of up-to-date nameservers changes
+func waitDNSPropagation(targets []*dnsPropagationTarget, timeout time.Duration) error {
+	if len(targets) == 0 {
+		logger.Println("No changed records to wait for")
+		return nil
+	}
+	logger.Printf("Waiting for propagation of %d records...\n", len(targets))
+	start := time.Now()
+	deadline := start.Add(timeout)
+	progress := make(map[*dnsPropagationTarget]int)
+	pending := targets
+	for _, target := range targets {
+		progress[target] = -1
+	}
+	lastMismatch := make(map[*dnsPropagationTarget]*dnsVerification)
+	for {
+		var next []*dnsPropagationTarget
+		for _, target := range pending {
+			updated := 0
+			for _, nameserver := range target.Nameservers {
+				result := checkDNSAnswers(nameserver, &target.dnsAnswerTarget, dnsPropagationQueryTimeout)
+				if result.Status == VerifyOK {
+					updated++
+				} else {
+					lastMismatch[target] = result
+				}
+			}
+			if updated == len(target.Nameservers) {
+				logger.Printf("  %s %s: live after %s\n", target.Name, target.Type, time.Since(start).Round(time.Second))
+				continue
+			}
+			if updated != progress[target] {
+				mismatch := lastMismatch[target]
+				logger.Printf("  %s %s: %d/%d nameservers up to date, %s: %s\n",
+					target.Name, target.Type, updated, len(target.Nameservers), mismatch.Nameserver, mismatch.Details)
+				progress[target] = updated
+			}
+			next = append(next, target)
+		}
+		pending = next
+		if len(pending) == 0 {
+			logger.Println("All changes are live")
+			return nil
+		}
+		if time.Now().Add(dnsPropagationPollInterval).After(deadline) {
+			lines := make([]string, len(pending))
+			for i, target := range pending {
+				mismatch := lastMismatch[target]
+				lines[i] = fmt.Sprintf("%s %s (%s: %s)", target.Name, target.Type, mismatch.Nameserver, mismatch.Details)
+			}
+			return fmt.Errorf("%d records are not live after %s:\n  %s", len(pending), timeout, strings.Join(lines, "\n  "))
+		}
+		time.Sleep(dnsPropagationPollInterval)
+	}
+}
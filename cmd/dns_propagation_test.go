@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestWaitDNSPropagation(t *testing.T) {
+	originalInterval := dnsPropagationPollInterval
+	dnsPropagationPollInterval = 10 * time.Millisecond
+	defer func() {
+		dnsPropagationPollInterval = originalInterval
+	}()
+
+	configFile := writeTestConfig(t, map[string]string{
+		"main.yaml": `
+constellix:
+  dns:
+    example.com:
+      - example.yaml
+`,
+		"example.yaml": `
+- name: www
+  type: A
+  ttl: 60
+  mode: standard
+  region: default
+  value:
+    - value: 192.0.2.2
+      enabled: true
+- name: new
+  type: A
+  mode: standard
+  region: default
+  value:
+    - value: 192.0.2.3
+      enabled: true
+- name: api
+  type: A
+  ttl: 60
+  mode: standard
+  region: default
+  value:
+    - value: 192.0.2.10
+      enabled: true
+- name: failover
+  type: A
+  ttl: 60
+  mode: failover
+  region: default
+  value:
+    mode: normal
+    enabled: true
+    values:
+      - value: 192.0.2.30
+        order: 1
+        enabled: true
+`,
+	})
+	config, err := getConfig(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []*DNSRecord
+	err = json.Unmarshal([]byte(`[
+{"id":1,"name":"www","type":"A","ttl":60,"mode":"standard","region":"default","enabled":true,"value":[{"value":"192.0.2.1","enabled":true}]},
+{"id":2,"name":"api","type":"A","ttl":60,"mode":"standard","region":"default","enabled":true,"value":[{"value":"192.0.2.10","enabled":true}]},
+{"id":3,"name":"old","type":"A","ttl":300,"mode":"standard","region":"default","enabled":true,"value":[{"value":"192.0.2.9","enabled":true}]}
+]`), &records)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := startTestDNSServer(t, map[string][]testDNSRecord{
+		"www.example.com. A": {{60, testDNSAddress("192.0.2.1")}},
+		"api.example.com. A": {{60, testDNSAddress("192.0.2.10")}},
+		"old.example.com. A": {{300, testDNSAddress("192.0.2.9")}},
+	})
+	targets, err := dnsPropagationTargets("example.com", []string{server.addr}, config.DNS["example.com"], records, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, target := range targets {
+		names = append(names, target.Name+" "+target.Type)
+	}
+	// Unchanged records and records in failover mode are not checked
+	expectedNames := []string{"www.example.com. A", "new.example.com. A", "old.example.com. A"}
+	if len(names) != len(expectedNames) {
+		t.Fatalf("expected targets %q, got %q", expectedNames, names)
+	}
+	for i := range names {
+		if names[i] != expectedNames[i] {
+			t.Fatalf("expected targets %q, got %q", expectedNames, names)
+		}
+	}
+
+	// Nameservers are not updated yet
+	err = waitDNSPropagation(targets, 50*time.Millisecond)
+	expectedErr := "3 records are not live after 50ms:\n" +
+		"  www.example.com. A (" + server.addr + ": missing 192.0.2.2; unexpected 192.0.2.1)\n" +
+		"  new.example.com. A (" + server.addr + ": missing 192.0.2.3)\n" +
+		"  old.example.com. A (" + server.addr + ": unexpected 192.0.2.9)"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("expected error %q, got %v", expectedErr, err)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		server.set("www.example.com. A", testDNSRecord{60, testDNSAddress("192.0.2.2")})
+		// TTL of the record is not defined and is not checked
+		server.set("new.example.com. A", testDNSRecord{3600, testDNSAddress("192.0.2.3")})
+		server.set("old.example.com. A")
+	}()
+	err = waitDNSPropagation(targets, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
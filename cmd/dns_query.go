@@ -0,0 +1,365 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+)
+
+// Codes of resource record types which can be queried
+var dnsQueryTypes = map[string]uint16{
+	"A":     1,
+	"NS":    2,
+	"CNAME": 5,
+	"PTR":   12,
+	"HINFO": 13,
+	"MX":    15,
+	"TXT":   16,
+	"RP":    17,
+	"AAAA":  28,
+	"SRV":   33,
+	"NAPTR": 35,
+	"CERT":  37,
+	"SPF":   99,
+	"CAA":   257,
+}
+
+const (
+	dnsClassIN = 1
+	dnsTypeOPT = 41
+	// UDP payload size advertised via EDNS, larger responses are truncated
+	dnsUDPPayloadSize = 4096
+	dnsRcodeNXDomain  = 3
+)
+
+// dnsAnswer is a resource record from the answer section of the response
+type dnsAnswer struct {
+	TTL int
+	// Data of the record in zone file presentation format
+	Data string
+}
+
+// dnsResponse is the parsed response of the nameserver
+type dnsResponse struct {
+	Authoritative bool
+	Rcode         int
+	// Records of the requested name and type, other records are dropped
+	Answers []*dnsAnswer
+}
+
+// queryDNS sends non-recursive query to the nameserver over UDP. Truncated
+// responses are retried over TCP. Port 53 is used if the address has no port
+func queryDNS(nameserver, name, recordType string, timeout time.Duration) (*dnsResponse, error) {
+	qtype, ok := dnsQueryTypes[recordType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported record type %s", recordType)
+	}
+	if _, _, err := net.SplitHostPort(nameserver); err != nil {
+		nameserver = net.JoinHostPort(nameserver, "53")
+	}
+	id := uint16(rand.Intn(1 << 16))
+	query, err := buildDNSQuery(id, name, qtype)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := exchangeDNSMessage("udp", nameserver, query, timeout)
+	if err != nil {
+		return nil, err
+	}
+	response, truncated, err := parseDNSResponse(message, id, name, qtype)
+	if err != nil {
+		return nil, err
+	}
+	if !truncated {
+		return response, nil
+	}
+	message, err = exchangeDNSMessage("tcp", nameserver, query, timeout)
+	if err != nil {
+		return nil, err
+	}
+	response, _, err = parseDNSResponse(message, id, name, qtype)
+	return response, err
+}
+
+// exchangeDNSMessage sends the message and reads the response. Messages sent
+// over TCP are prefixed with their length
+func exchangeDNSMessage(network, nameserver string, query []byte, timeout time.Duration) ([]byte, error) {
+	conn, err := net.DialTimeout(network, nameserver, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	err = conn.SetDeadline(time.Now().Add(timeout))
+	if err != nil {
+		return nil, err
+	}
+	if network == "udp" {
+		_, err = conn.Write(query)
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, dnsUDPPayloadSize)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+	_, err = conn.Write(binary.BigEndian.AppendUint16(nil, uint16(len(query))))
+	if err != nil {
+		return nil, err
+	}
+	_, err = conn.Write(query)
+	if err != nil {
+		return nil, err
+	}
+	var length [2]byte
+	_, err = io.ReadFull(conn, length[:])
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, binary.BigEndian.Uint16(length[:]))
+	_, err = io.ReadFull(conn, buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// buildDNSQuery returns the query with a single question and EDNS record
+func buildDNSQuery(id uint16, name string, qtype uint16) ([]byte, error) {
+	msg := make([]byte, 12)
+	binary.BigEndian.PutUint16(msg[0:], id)
+	// One question and one additional record, recursion is not desired
+	binary.BigEndian.PutUint16(msg[4:], 1)
+	binary.BigEndian.PutUint16(msg[10:], 1)
+	msg, err := appendDNSName(msg, name)
+	if err != nil {
+		return nil, err
+	}
+	msg = binary.BigEndian.AppendUint16(msg, qtype)
+	msg = binary.BigEndian.AppendUint16(msg, dnsClassIN)
+	// OPT pseudo-record: root name, type, payload size, extended flags and
+	// empty data
+	msg = append(msg, 0)
+	msg = binary.BigEndian.AppendUint16(msg, dnsTypeOPT)
+	msg = binary.BigEndian.AppendUint16(msg, dnsUDPPayloadSize)
+	msg = binary.BigEndian.AppendUint32(msg, 0)
+	msg = binary.BigEndian.AppendUint16(msg, 0)
+	return msg, nil
+}
+
+// appendDNSName appends the name in wire format without compression
+func appendDNSName(msg []byte, name string) ([]byte, error) {
+	name = strings.TrimSuffix(name, ".")
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			if len(label) == 0 || len(label) > 63 {
+				return nil, fmt.Errorf("invalid name %q", name)
+			}
+			msg = append(msg, byte(len(label)))
+			msg = append(msg, label...)
+		}
+	}
+	return append(msg, 0), nil
+}
+
+// dnsMessageReader reads fields of the message, the first error is kept and
+// subsequent reads return zero values
+type dnsMessageReader struct {
+	msg    []byte
+	offset int
+	err    error
+}
+
+func (r *dnsMessageReader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *dnsMessageReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.offset+n > len(r.msg) {
+		r.fail(fmt.Errorf("malformed DNS message: unexpected end at offset %d", r.offset))
+		return nil
+	}
+	b := r.msg[r.offset : r.offset+n]
+	r.offset += n
+	return b
+}
+
+func (r *dnsMessageReader) uint8() uint8 {
+	b := r.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (r *dnsMessageReader) uint16() uint16 {
+	b := r.bytes(2)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(b)
+}
+
+func (r *dnsMessageReader) uint32() uint32 {
+	b := r.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+// name reads the domain name, compression pointers are followed. The name is
+// returned in lower case with trailing dot
+func (r *dnsMessageReader) name() string {
+	var labels []string
+	offset := r.offset
+	jumped := false
+	// Every pointer must point backwards, it limits the number of jumps
+	for jumps := 0; r.err == nil; jumps++ {
+		if offset >= len(r.msg) || jumps > len(r.msg) {
+			r.fail(fmt.Errorf("malformed DNS message: invalid name at offset %d", offset))
+			return ""
+		}
+		length := int(r.msg[offset])
+		switch {
+		case length == 0:
+			if !jumped {
+				r.offset = offset + 1
+			}
+			return strings.ToLower(strings.Join(labels, ".")) + "."
+		case length&0xC0 == 0xC0:
+			if offset+1 >= len(r.msg) {
+				r.fail(fmt.Errorf("malformed DNS message: invalid name at offset %d", offset))
+				return ""
+			}
+			if !jumped {
+				r.offset = offset + 2
+			}
+			jumped = true
+			pointer := int(binary.BigEndian.Uint16(r.msg[offset:]) & 0x3FFF)
+			if pointer >= offset {
+				r.fail(fmt.Errorf("malformed DNS message: invalid name at offset %d", offset))
+				return ""
+			}
+			offset = pointer
+		default:
+			if offset+1+length > len(r.msg) {
+				r.fail(fmt.Errorf("malformed DNS message: invalid name at offset %d", offset))
+				return ""
+			}
+			labels = append(labels, string(r.msg[offset+1:offset+1+length]))
+			offset += 1 + length
+		}
+	}
+	return ""
+}
+
+// characterString reads the length-prefixed string and returns it quoted
+func (r *dnsMessageReader) characterString() string {
+	length := r.uint8()
+	return quoteZoneString(string(r.bytes(int(length))))
+}
+
+// parseDNSResponse parses the response to the query. It reports whether the
+// response is truncated
+func parseDNSResponse(msg []byte, id uint16, name string, qtype uint16) (*dnsResponse, bool, error) {
+	r := &dnsMessageReader{msg: msg}
+	responseID := r.uint16()
+	flags := r.uint16()
+	qdcount := r.uint16()
+	ancount := r.uint16()
+	r.bytes(4)
+	if r.err != nil {
+		return nil, false, r.err
+	}
+	if responseID != id || flags&0x8000 == 0 {
+		return nil, false, fmt.Errorf("unexpected DNS message: not a response to the query")
+	}
+	response := &dnsResponse{
+		Authoritative: flags&0x0400 != 0,
+		Rcode:         int(flags & 0x000F),
+	}
+	truncated := flags&0x0200 != 0
+	for i := 0; i < int(qdcount); i++ {
+		r.name()
+		r.bytes(4)
+	}
+	name = strings.ToLower(toFQDN(name, "."))
+	for i := 0; i < int(ancount) && r.err == nil; i++ {
+		rrName := r.name()
+		rrType := r.uint16()
+		r.uint16()
+		ttl := r.uint32()
+		length := int(r.uint16())
+		end := r.offset + length
+		if rrName != name || rrType != qtype {
+			r.bytes(length)
+			continue
+		}
+		data := r.rdata(rrType, end)
+		if r.err == nil && r.offset != end {
+			r.fail(fmt.Errorf("malformed DNS message: invalid data of %s record", rrName))
+		}
+		response.Answers = append(response.Answers, &dnsAnswer{TTL: int(ttl), Data: data})
+	}
+	if r.err != nil {
+		return nil, false, r.err
+	}
+	return response, truncated, nil
+}
+
+// rdata reads data of the record and formats it like zone files do
+func (r *dnsMessageReader) rdata(rrType uint16, end int) string {
+	switch rrType {
+	case dnsQueryTypes["A"]:
+		return net.IP(r.bytes(4)).String()
+	case dnsQueryTypes["AAAA"]:
+		return net.IP(r.bytes(16)).String()
+	case dnsQueryTypes["NS"], dnsQueryTypes["CNAME"], dnsQueryTypes["PTR"]:
+		return r.name()
+	case dnsQueryTypes["MX"]:
+		preference := r.uint16()
+		return fmt.Sprintf("%d %s", preference, r.name())
+	case dnsQueryTypes["TXT"], dnsQueryTypes["SPF"]:
+		var chunks []string
+		for r.err == nil && r.offset < end {
+			chunks = append(chunks, r.characterString())
+		}
+		return strings.Join(chunks, " ")
+	case dnsQueryTypes["HINFO"]:
+		cpu := r.characterString()
+		return fmt.Sprintf("%s %s", cpu, r.characterString())
+	case dnsQueryTypes["RP"]:
+		mailbox := r.name()
+		return fmt.Sprintf("%s %s", mailbox, r.name())
+	case dnsQueryTypes["SRV"]:
+		priority, weight, port := r.uint16(), r.uint16(), r.uint16()
+		return fmt.Sprintf("%d %d %d %s", priority, weight, port, r.name())
+	case dnsQueryTypes["NAPTR"]:
+		order, preference := r.uint16(), r.uint16()
+		flags, service, regexp := r.characterString(), r.characterString(), r.characterString()
+		return fmt.Sprintf("%d %d %s %s %s %s", order, preference, flags, service, regexp, r.name())
+	case dnsQueryTypes["CERT"]:
+		certType, keyTag, algorithm := r.uint16(), r.uint16(), r.uint8()
+		certificate := base64.StdEncoding.EncodeToString(r.bytes(end - r.offset))
+		return fmt.Sprintf("%d %d %d %s", certType, keyTag, algorithm, certificate)
+	case dnsQueryTypes["CAA"]:
+		flags := r.uint8()
+		tag := string(r.bytes(int(r.uint8())))
+		return fmt.Sprintf("%d %s %s", flags, tag, quoteZoneString(string(r.bytes(end-r.offset))))
+	}
+	return ""
+}
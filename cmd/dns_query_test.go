@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testDNSRecord is a resource record served by testDNSServer
+type testDNSRecord struct {
+	ttl   uint32
+	rdata []byte
+}
+
+// testDNSServer answers queries over UDP and TCP on the same port. Records are
+// keyed by lower case FQDN and type, e.g. "www.example.com. A"
+type testDNSServer struct {
+	addr string
+	mu   sync.Mutex
+	zone map[string][]testDNSRecord
+	// UDP responses are truncated, clients must retry over TCP
+	truncate bool
+}
+
+func startTestDNSServer(t *testing.T, zone map[string][]testDNSRecord) *testDNSServer {
+	t.Helper()
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	udpConn, err := net.ListenPacket("udp", tcpListener.Addr().String())
+	if err != nil {
+		tcpListener.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		tcpListener.Close()
+		udpConn.Close()
+	})
+	s := &testDNSServer{addr: tcpListener.Addr().String(), zone: zone}
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := udpConn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			udpConn.WriteTo(s.respond(buf[:n], true), addr)
+		}
+	}()
+	go func() {
+		for {
+			conn, err := tcpListener.Accept()
+			if err != nil {
+				return
+			}
+			var length [2]byte
+			if _, err := io.ReadFull(conn, length[:]); err == nil {
+				query := make([]byte, binary.BigEndian.Uint16(length[:]))
+				if _, err := io.ReadFull(conn, query); err == nil {
+					response := s.respond(query, false)
+					conn.Write(binary.BigEndian.AppendUint16(nil, uint16(len(response))))
+					conn.Write(response)
+				}
+			}
+			conn.Close()
+		}
+	}()
+	return s
+}
+
+// set replaces records of the name and type
+func (s *testDNSServer) set(key string, records ...testDNSRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.zone[key] = records
+}
+
+func (s *testDNSServer) respond(query []byte, udp bool) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Question starts after the header, the name is not compressed
+	end := 12
+	var labels []string
+	for query[end] != 0 {
+		labels = append(labels, string(query[end+1:end+1+int(query[end])]))
+		end += 1 + int(query[end])
+	}
+	name := strings.ToLower(strings.Join(labels, ".")) + "."
+	qtype := binary.BigEndian.Uint16(query[end+1:])
+	end += 5
+
+	var typeName string
+	for k, v := range dnsQueryTypes {
+		if v == qtype {
+			typeName = k
+		}
+	}
+	records := s.zone[name+" "+typeName]
+	flags := uint16(0x8400)
+	exists := false
+	for key := range s.zone {
+		if strings.HasPrefix(key, name+" ") && len(s.zone[key]) > 0 {
+			exists = true
+		}
+	}
+	if !exists {
+		flags |= dnsRcodeNXDomain
+	}
+	if udp && s.truncate {
+		flags |= 0x0200
+		records = nil
+	}
+
+	msg := append([]byte{}, query[:2]...)
+	msg = binary.BigEndian.AppendUint16(msg, flags)
+	msg = binary.BigEndian.AppendUint16(msg, 1)
+	msg = binary.BigEndian.AppendUint16(msg, uint16(len(records)))
+	msg = binary.BigEndian.AppendUint32(msg, 0)
+	msg = append(msg, query[12:end]...)
+	for _, record := range records {
+		// Pointer to the name of the question
+		msg = append(msg, 0xC0, 12)
+		msg = binary.BigEndian.AppendUint16(msg, qtype)
+		msg = binary.BigEndian.AppendUint16(msg, dnsClassIN)
+		msg = binary.BigEndian.AppendUint32(msg, record.ttl)
+		msg = binary.BigEndian.AppendUint16(msg, uint16(len(record.rdata)))
+		msg = append(msg, record.rdata...)
+	}
+	return msg
+}
+
+func testDNSAddress(ip string) []byte {
+	if v4 := net.ParseIP(ip).To4(); v4 != nil {
+		return v4
+	}
+	return net.ParseIP(ip).To16()
+}
+
+func testDNSName(name string) []byte {
+	b, _ := appendDNSName(nil, name)
+	return b
+}
+
+func testDNSStrings(chunks ...string) []byte {
+	var b []byte
+	for _, chunk := range chunks {
+		b = append(b, byte(len(chunk)))
+		b = append(b, chunk...)
+	}
+	return b
+}
+
+func TestQueryDNS(t *testing.T) {
+	server := startTestDNSServer(t, map[string][]testDNSRecord{
+		"example.com. A":    {{300, testDNSAddress("192.0.2.1")}, {300, testDNSAddress("192.0.2.2")}},
+		"example.com. AAAA": {{300, testDNSAddress("2001:db8::1")}},
+		"example.com. MX":   {{3600, append([]byte{0, 10}, testDNSName("Mail.Example.com.")...)}},
+		"example.com. TXT":  {{60, testDNSStrings(`v=spf1 "a"`, " -all")}},
+		"example.com. CAA":  {{60, append([]byte{0, 5}, "issueletsencrypt.org"...)}},
+		"_sip._tcp.example.com. SRV": {
+			{60, append([]byte{0, 10, 0, 20, 0x13, 0xC4}, testDNSName("sip.example.com.")...)},
+		},
+	})
+
+	tests := []struct {
+		name, recordType string
+		expected         []string
+	}{
+		{"example.com", "A", []string{"300 192.0.2.1", "300 192.0.2.2"}},
+		{"example.com.", "AAAA", []string{"300 2001:db8::1"}},
+		{"EXAMPLE.com", "MX", []string{"3600 10 mail.example.com."}},
+		{"example.com", "TXT", []string{`60 "v=spf1 \"a\"" " -all"`}},
+		{"example.com", "CAA", []string{`60 0 issue "letsencrypt.org"`}},
+		{"_sip._tcp.example.com", "SRV", []string{"60 10 20 5060 sip.example.com."}},
+		{"example.com", "NS", nil},
+	}
+	for _, test := range tests {
+		response, err := queryDNS(server.addr, test.name, test.recordType, time.Second)
+		if err != nil {
+			t.Fatalf("%s %s: %s", test.name, test.recordType, err)
+		}
+		var answers []string
+		for _, answer := range response.Answers {
+			answers = append(answers, fmt.Sprintf("%d %s", answer.TTL, answer.Data))
+		}
+		if strings.Join(answers, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("%s %s: expected %q, got %q", test.name, test.recordType, test.expected, answers)
+		}
+		if !response.Authoritative || response.Rcode != 0 {
+			t.Errorf("%s %s: unexpected flags %+v", test.name, test.recordType, response)
+		}
+	}
+
+	response, err := queryDNS(server.addr, "missing.example.com", "A", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Rcode != dnsRcodeNXDomain || len(response.Answers) != 0 {
+		t.Errorf("expected NXDOMAIN, got %+v", response)
+	}
+
+	// Truncated responses are retried over TCP
+	server.mu.Lock()
+	server.truncate = true
+	server.mu.Unlock()
+	response, err = queryDNS(server.addr, "example.com", "A", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Answers) != 2 {
+		t.Errorf("expected 2 answers over TCP, got %d", len(response.Answers))
+	}
+}
+
+func TestParseDNSResponse_malformed(t *testing.T) {
+	query, err := buildDNSQuery(1, "example.com", dnsQueryTypes["A"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	response := append([]byte{}, query...)
+	response[2] |= 0x80
+	// One answer with a pointer to itself
+	response[7] = 1
+	response = append(response[:len(response)-11], 0xC0, byte(len(response)-11))
+	_, _, err = parseDNSResponse(response, 1, "example.com", dnsQueryTypes["A"])
+	if err == nil || !strings.Contains(err.Error(), "invalid name") {
+		t.Errorf("expected invalid name error, got %v", err)
+	}
+
+	_, _, err = parseDNSResponse(query[:8], 1, "example.com", dnsQueryTypes["A"])
+	if err == nil {
+		t.Errorf("expected error for short message")
+	}
+}
@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"golang.org/x/exp/slices"
+)
+
+type DNSVerifyStatus string
+
+const VerifyOK DNSVerifyStatus = "ok"
+const VerifyMismatch DNSVerifyStatus = "mismatch"
+const VerifySkipped DNSVerifyStatus = "skipped"
+const VerifyError DNSVerifyStatus = "error"
+
+// Names of response codes which are expected from nameservers
+var dnsRcodeNames = map[int]string{1: "FORMERR", 2: "SERVFAIL", 3: "NXDOMAIN", 4: "NOTIMP", 5: "REFUSED"}
+
+// dnsVerification is the result of querying the nameserver for the record
+type dnsVerification struct {
+	Name       string
+	Type       string
+	Nameserver string
+	Status     DNSVerifyStatus
+	Details    string
+}
+
+// verifyDNSRecords queries every nameserver for standard-mode records of the
+// domain and compares answers with values and TTLs of the records. Records of
+// other regions, with geoproximity or IP filter are skipped: their answers
+// depend on the location of the client
+func verifyDNSRecords(domainName string, records []*ExpectedDNSRecord, nameservers []string, timeout time.Duration) []*dnsVerification {
+	var standard []*ExpectedDNSRecord
+	for _, record := range records {
+		if record.Mode == "standard" {
+			standard = append(standard, record)
+		}
+	}
+	sort.SliceStable(standard, func(i, j int) bool {
+		if standard[i].Name != standard[j].Name {
+			return standard[i].Name < standard[j].Name
+		}
+		return standard[i].Type < standard[j].Type
+	})
+
+	var results []*dnsVerification
+	for _, record := range standard {
+		name := dnsRecordFQDN(record.Name, domainName)
+		if reason := dnsVerifySkipReason(&record.DNSRecord); reason != "" {
+			results = append(results, &dnsVerification{name, record.Type, "-", VerifySkipped, reason})
+			continue
+		}
+		for _, nameserver := range nameservers {
+			results = append(results, verifyDNSRecord(nameserver, domainName, record, timeout))
+		}
+	}
+	return results
+}
+
+// dnsRecordFQDN returns the absolute name of the record, empty name is the
+// apex of the domain
+func dnsRecordFQDN(name, domainName string) string {
+	if name == "" {
+		return domainName + "."
+	}
+	return toFQDN(name, domainName+".")
+}
+
+// dnsVerifySkipReason returns why the record can't be verified
+func dnsVerifySkipReason(record *DNSRecord) string {
+	if _, ok := dnsQueryTypes[record.Type]; !ok {
+		return "record type can't be queried"
+	}
+	if (record.Region != "" && record.Region != "default") || record.GeoProximity != nil || record.IPFilter != nil {
+		return "answer depends on location of the client"
+	}
+	return ""
+}
+
+// dnsAnswerTarget is the state of the record which nameservers are expected
+// to return
+type dnsAnswerTarget struct {
+	Name string
+	Type string
+	// TTL has no default value, it's checked only if it's defined
+	TTL *int
+	// Normalized values, deleted and disabled records have no values
+	Values []string
+}
+
+// newDNSAnswerTarget returns the expected answer of the record
+func newDNSAnswerTarget(domainName string, record *ExpectedDNSRecord) *dnsAnswerTarget {
+	target := &dnsAnswerTarget{
+		Name:   dnsRecordFQDN(record.Name, domainName),
+		Type:   record.Type,
+		Values: expectedDNSAnswers(record),
+	}
+	if slices.Contains(record.GetDefinedStructFieldNames(), "TTL") {
+		ttl := record.TTL
+		target.TTL = &ttl
+	}
+	return target
+}
+
+// verifyDNSRecord queries the nameserver for the record
+func verifyDNSRecord(nameserver, domainName string, record *ExpectedDNSRecord, timeout time.Duration) *dnsVerification {
+	return checkDNSAnswers(nameserver, newDNSAnswerTarget(domainName, record), timeout)
+}
+
+// checkDNSAnswers queries the nameserver and compares answers with the target
+func checkDNSAnswers(nameserver string, target *dnsAnswerTarget, timeout time.Duration) *dnsVerification {
+	result := &dnsVerification{Name: target.Name, Type: target.Type, Nameserver: nameserver}
+	response, err := queryDNS(nameserver, target.Name, target.Type, timeout)
+	if err != nil {
+		result.Status = VerifyError
+		result.Details = err.Error()
+		return result
+	}
+	if response.Rcode != 0 && response.Rcode != dnsRcodeNXDomain {
+		result.Status = VerifyError
+		rcode, ok := dnsRcodeNames[response.Rcode]
+		if !ok {
+			rcode = fmt.Sprint(response.Rcode)
+		}
+		result.Details = "response code " + rcode
+		return result
+	}
+
+	var actual []string
+	var problems []string
+	for _, answer := range response.Answers {
+		actual = append(actual, normalizeDNSData(target.Type, answer.Data))
+		if target.TTL != nil && answer.TTL != *target.TTL {
+			problem := fmt.Sprintf("TTL %d, expected %d", answer.TTL, *target.TTL)
+			if !slices.Contains(problems, problem) {
+				problems = append(problems, problem)
+			}
+		}
+	}
+	var missing, unexpected []string
+	for _, value := range target.Values {
+		if !slices.Contains(actual, value) {
+			missing = append(missing, value)
+		}
+	}
+	for _, value := range actual {
+		if !slices.Contains(target.Values, value) {
+			unexpected = append(unexpected, value)
+		}
+	}
+	if len(missing) > 0 {
+		problems = append(problems, "missing "+strings.Join(missing, ", "))
+	}
+	if len(unexpected) > 0 {
+		problems = append(problems, "unexpected "+strings.Join(unexpected, ", "))
+	}
+	if len(problems) > 0 {
+		result.Status = VerifyMismatch
+		result.Details = strings.Join(problems, "; ")
+		return result
+	}
+	result.Status = VerifyOK
+	return result
+}
+
+// expectedDNSAnswers returns normalized enabled values of the record. Disabled
+// records are not answered
+func expectedDNSAnswers(record *ExpectedDNSRecord) []string {
+	// Records are enabled unless it's defined otherwise
+	if slices.Contains(record.GetDefinedStructFieldNames(), "Enabled") && !record.Enabled {
+		return nil
+	}
+	var values []string
+	for _, data := range dnsRecordZoneValues(&record.DNSRecord, nil, &zoneAnswer{}) {
+		values = append(values, normalizeDNSData(record.Type, data))
+	}
+	return values
+}
+
+// normalizeDNSData converts data of the record in presentation format into
+// comparable form: addresses are canonical, names are in lower case without
+// trailing dot, character strings of TXT records are concatenated
+func normalizeDNSData(recordType, data string) string {
+	switch recordType {
+	case "A", "AAAA":
+		if ip := net.ParseIP(data); ip != nil {
+			return ip.String()
+		}
+		return data
+	}
+	tokens, _, err := tokenizeZoneLine(data)
+	if err != nil {
+		return data
+	}
+	if recordType == "TXT" || recordType == "SPF" {
+		var sb strings.Builder
+		for _, token := range tokens {
+			sb.WriteString(unescapeZoneString(token.Text))
+		}
+		return quoteZoneString(sb.String())
+	}
+	parts := make([]string, len(tokens))
+	for i, token := range tokens {
+		switch {
+		case token.Quoted:
+			parts[i] = quoteZoneString(unescapeZoneString(token.Text))
+		case recordType == "CERT":
+			// Certificate is case-sensitive base64
+			parts[i] = token.Text
+		default:
+			parts[i] = strings.ToLower(strings.TrimSuffix(token.Text, "."))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// reportDNSVerifications prints results of verification
+func reportDNSVerifications(results []*dnsVerification) {
+	report := table.NewWriter()
+	if reportToTestBuffer {
+		// Skip header in tests
+		report.SetOutputMirror(testBuffer)
+	} else {
+		report.SetOutputMirror(os.Stdout)
+		report.AppendHeader(table.Row{"Name", "Type", "Nameserver", "Status", "Details"})
+	}
+	for _, result := range results {
+		status := string(result.Status)
+		if !reportToTestBuffer {
+			status = colorVerifyStatus(result.Status)
+		}
+		report.AppendRow(table.Row{result.Name, result.Type, result.Nameserver, status, result.Details})
+	}
+	if report.Length() == 0 {
+		logger.Println("  no standard-mode records to verify")
+		return
+	}
+	printReport(report)
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVerifyDNSRecords(t *testing.T) {
+	configFile := writeTestConfig(t, map[string]string{
+		"main.yaml": `
+constellix:
+  dns:
+    example.com:
+      - example.yaml
+`,
+		"example.yaml": `
+- name: ""
+  type: A
+  ttl: 300
+  mode: standard
+  region: default
+  value:
+    - value: 192.0.2.1
+      enabled: true
+    - value: 192.0.2.2
+      enabled: false
+- name: www
+  type: CNAME
+  ttl: 600
+  mode: standard
+  region: default
+  value:
+    - value: web.example.net
+      enabled: true
+- name: ""
+  type: MX
+  ttl: 3600
+  mode: standard
+  region: default
+  value:
+    - server: mail.example.com.
+      priority: 10
+      enabled: true
+- name: ""
+  type: TXT
+  ttl: 300
+  mode: standard
+  region: default
+  value:
+    - value: '"v=spf1 -all"'
+      enabled: true
+- name: old
+  type: A
+  ttl: 300
+  mode: standard
+  region: default
+  enabled: false
+  value:
+    - value: 192.0.2.9
+      enabled: true
+- name: api
+  type: A
+  ttl: 60
+  mode: standard
+  region: default
+  value:
+    - value: 192.0.2.10
+      enabled: true
+- name: api
+  type: A
+  ttl: 60
+  mode: standard
+  region: europe
+  value:
+    - value: 192.0.2.20
+      enabled: true
+- name: cdn
+  type: ANAME
+  ttl: 60
+  mode: standard
+  region: default
+  value:
+    - value: cdn.example.net.
+      enabled: true
+- name: mail
+  type: A
+  mode: standard
+  region: default
+  value:
+    - value: 192.0.2.40
+      enabled: true
+- name: failover
+  type: A
+  ttl: 60
+  mode: failover
+  region: default
+  value:
+    mode: normal
+    enabled: true
+    values:
+      - value: 192.0.2.30
+        order: 1
+        enabled: true
+`,
+	})
+	config, err := getConfig(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := startTestDNSServer(t, map[string][]testDNSRecord{
+		"example.com. A":         {{300, testDNSAddress("192.0.2.1")}},
+		"www.example.com. CNAME": {{600, testDNSName("Web.Example.NET.")}},
+		"example.com. MX":        {{300, append([]byte{0, 10}, testDNSName("mail.example.com.")...)}},
+		"example.com. TXT":       {{300, testDNSStrings("v=spf1 ", "-all")}},
+		"api.example.com. A":     {{60, testDNSAddress("192.0.2.11")}},
+		"mail.example.com. A":    {{3600, testDNSAddress("192.0.2.40")}},
+	})
+
+	results := verifyDNSRecords("example.com", config.DNS["example.com"], []string{server.addr}, time.Second)
+	reportToTestBuffer = true
+	defer func() {
+		reportToTestBuffer = false
+		testBuffer.Reset()
+	}()
+	reportDNSVerifications(results)
+	ns := server.addr
+	expected := "example.com.,A," + ns + ",ok,\n" +
+		"example.com.,MX," + ns + ",mismatch,\"TTL 300\\, expected 3600\"\n" +
+		"example.com.,TXT," + ns + ",ok,\n" +
+		"api.example.com.,A," + ns + ",mismatch,missing 192.0.2.10; unexpected 192.0.2.11\n" +
+		"api.example.com.,A,-,skipped,answer depends on location of the client\n" +
+		"cdn.example.com.,ANAME,-,skipped,record type can't be queried\n" +
+		"mail.example.com.,A," + ns + ",ok,\n" +
+		"old.example.com.,A," + ns + ",ok,\n" +
+		"www.example.com.,CNAME," + ns + ",ok,\n"
+	if testBuffer.String() != expected {
+		t.Errorf("expected report:\n%s\ngot:\n%s", expected, testBuffer.String())
+	}
+}
+
+func TestNormalizeDNSData(t *testing.T) {
+	tests := []struct {
+		recordType, data, expected string
+	}{
+		{"AAAA", "2001:DB8:0::1", "2001:db8::1"},
+		{"CNAME", "Web.Example.NET.", "web.example.net"},
+		{"TXT", `"v=spf1 " "-all"`, `"v=spf1 -all"`},
+		{"TXT", `"say \"hi\""`, `"say \"hi\""`},
+		{"CAA", `0 issue "letsencrypt.org"`, `0 issue "letsencrypt.org"`},
+		{"CERT", "1 0 0 AbCd", "1 0 0 AbCd"},
+	}
+	for _, test := range tests {
+		if got := normalizeDNSData(test.recordType, test.data); got != test.expected {
+			t.Errorf("%s %q: expected %q, got %q", test.recordType, test.data, test.expected, got)
+		}
+	}
+}
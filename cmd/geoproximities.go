@@ -58,6 +58,8 @@ type ExpectedGeoProximity struct {
 	immutableFields []string
 	// List of mandatory fields which must be defined, used for validation
 	mandatoryFields []string
+	// Location of the resource in configuration files
+	source string
 	GeoProximity
 }
 
@@ -102,6 +104,16 @@ func (ex *ExpectedGeoProximity) Validate() error {
 	return nil
 }
 
+// GetSource returns location of the resource in configuration files
+func (ex *ExpectedGeoProximity) GetSource() string {
+	return ex.source
+}
+
+// SetSource sets location of the resource in configuration files
+func (ex *ExpectedGeoProximity) SetSource(source string) {
+	ex.source = source
+}
+
 // GetDefinedStructFieldNames returns list of defined struct fields from local configuration
 func (ex *ExpectedGeoProximity) GetDefinedStructFieldNames() []string {
 	return maps.Values(ex.definedFieldsMap)
@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Continent codes accepted by Constellix
+var supportedIPFilterContinents = []string{"AF", "AN", "AS", "EU", "NA", "OC", "SA"}
+
+// ISO 3166-1 alpha-2 country code
+var countryCodeRe = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// IPFilter limits the clients which receive the record
+type IPFilter struct {
+	ID         int               `json:"id"`
+	Name       string            `json:"name" yaml:"name"`
+	RulesLimit int               `json:"rulesLimit" yaml:"rulesLimit"`
+	Continents []string          `json:"continents" yaml:"continents"`
+	Countries  []string          `json:"countries" yaml:"countries"`
+	Regions    []*IPFilterRegion `json:"regions" yaml:"regions"`
+	ASN        []int             `json:"asn" yaml:"asn"`
+	IPv4       []string          `json:"ipv4" yaml:"ipv4"`
+	IPv6       []string          `json:"ipv6" yaml:"ipv6"`
+}
+
+type IPFilterRegion struct {
+	Continent string `json:"continent" yaml:"continent"`
+	Country   string `json:"country" yaml:"country"`
+	Region    string `json:"region" yaml:"region"`
+}
+
+func (ac *IPFilter) GetResource() interface{} {
+	return ac
+}
+
+func (ac *IPFilter) GetResourceID() string {
+	return ac.Name
+}
+
+func (ac *IPFilter) GetConstellixID() int {
+	return ac.ID
+}
+
+func (ac *IPFilter) SyncResourceDelete(constellixID int) error {
+	logger.Printf("  removing resource %q\n", ac.GetResourceID())
+	endpoint, err := url.JoinPath(dnsRESTAPIBaseURL, "ipfilters", fmt.Sprint(constellixID))
+	if err != nil {
+		return err
+	}
+	data, err := makev4APIRequest("DELETE", endpoint, nil, 204)
+	if err != nil {
+		var details string
+		for _, item := range data {
+			details += string(item)
+		}
+		logger.Println("  unexpected response. Details: " + details)
+		return fmt.Errorf("unable to delete IP filter: %s", err)
+	}
+	return nil
+}
+
+type ExpectedIPFilter struct {
+	// Mapping of defined fields from parsed data to struct Field Names
+	definedFieldsMap map[string]string
+	// List of immutable fields which can't be updated via API
+	immutableFields []string
+	// List of mandatory fields which must be defined, used for validation
+	mandatoryFields []string
+	// Location of the resource in configuration files
+	source string
+	IPFilter
+}
+
+// UnmarshalYAML unmarshals the mesage and stores original fields
+func (ex *ExpectedIPFilter) UnmarshalYAML(value *yaml.Node) error {
+	ex.immutableFields = []string{}
+	ex.mandatoryFields = []string{"name"}
+
+	// Unmarshall data into IPFilter struct
+	var s IPFilter
+	err := value.Decode(&s)
+	if err != nil {
+		return err
+	}
+	ex.IPFilter = s
+
+	// Save specified fields
+	dm := make(map[string]interface{})
+	err = value.Decode(&dm)
+	if err != nil {
+		return err
+	}
+
+	definedFields := make([]string, len(dm))
+	i := 0
+	for k := range dm {
+		definedFields[i] = k
+		i++
+	}
+	ex.definedFieldsMap = getFieldNamesMap(&ex.IPFilter, "yaml", definedFields...)
+	return nil
+}
+
+// Validate performs simple validation of user provided data
+func (ex *ExpectedIPFilter) Validate() error {
+	// Validate that all mandatory fields are present
+	for _, f := range ex.mandatoryFields {
+		if !slices.Contains(maps.Keys(ex.definedFieldsMap), f) {
+			return fmt.Errorf("%s: mandatory field %q is not defined", ex.Name, f)
+		}
+	}
+	for _, continent := range ex.Continents {
+		if !slices.Contains(supportedIPFilterContinents, continent) {
+			return fmt.Errorf("%s: unsupported continent %q, expected one of %q", ex.Name, continent, supportedIPFilterContinents)
+		}
+	}
+	for _, country := range ex.Countries {
+		if !countryCodeRe.MatchString(country) {
+			return fmt.Errorf("%s: invalid country %q, expected ISO 3166-1 alpha-2 code", ex.Name, country)
+		}
+	}
+	for _, region := range ex.Regions {
+		if !countryCodeRe.MatchString(region.Country) {
+			return fmt.Errorf("%s: invalid country %q in region, expected ISO 3166-1 alpha-2 code", ex.Name, region.Country)
+		}
+	}
+	for _, asn := range ex.ASN {
+		if asn < 0 || int64(asn) > 4294967295 {
+			return fmt.Errorf("%s: ASN must be in range 0-4294967295, got %d", ex.Name, asn)
+		}
+	}
+	for _, cidr := range ex.IPv4 {
+		ip, _, err := net.ParseCIDR(cidr)
+		if err != nil || ip.To4() == nil {
+			return fmt.Errorf("%s: invalid IPv4 network %q", ex.Name, cidr)
+		}
+	}
+	for _, cidr := range ex.IPv6 {
+		ip, _, err := net.ParseCIDR(cidr)
+		if err != nil || ip.To4() != nil {
+			return fmt.Errorf("%s: invalid IPv6 network %q", ex.Name, cidr)
+		}
+	}
+	return nil
+}
+
+// GetSource returns location of the resource in configuration files
+func (ex *ExpectedIPFilter) GetSource() string {
+	return ex.source
+}
+
+// SetSource sets location of the resource in configuration files
+func (ex *ExpectedIPFilter) SetSource(source string) {
+	ex.source = source
+}
+
+// GetDefinedStructFieldNames returns list of defined struct fields from local configuration
+func (ex *ExpectedIPFilter) GetDefinedStructFieldNames() []string {
+	return maps.Values(ex.definedFieldsMap)
+}
+
+// GetImmutableStructFields returns list of immutable struct fields
+func (ex *ExpectedIPFilter) GetImmutableStructFields() []string {
+	var imf []string
+	for k, v := range ex.definedFieldsMap {
+		if slices.Contains(ex.immutableFields, k) {
+			imf = append(imf, v)
+		}
+	}
+	return imf
+}
+
+func (ex *ExpectedIPFilter) GetResource() interface{} {
+	return ex.IPFilter
+}
+
+func (ex *ExpectedIPFilter) GetResourceID() string {
+	return ex.Name
+}
+
+func (ex *ExpectedIPFilter) SyncResourceUpdate(constellixID int) error {
+	logger.Printf("  updating resource %q\n", ex.GetResourceID())
+	endpoint, err := url.JoinPath(dnsRESTAPIBaseURL, "ipfilters", fmt.Sprint(constellixID))
+	if err != nil {
+		return err
+	}
+	payload, err := generatePayload(ex, maps.Keys(ex.definedFieldsMap), nil)
+	if err != nil {
+		return err
+	}
+	payloadReader := bytes.NewReader(payload)
+	data, err := makev4APIRequest("PUT", endpoint, payloadReader, 200)
+	if err != nil {
+		var details string
+		for _, item := range data {
+			details += string(item)
+		}
+		logger.Println("  unexpected response. Details: " + details)
+		return fmt.Errorf("unable to update IP filter: %s", err)
+	}
+	return nil
+}
+
+func (ex *ExpectedIPFilter) SyncResourceCreate() error {
+	logger.Printf("  creating new resource %q\n", ex.GetResourceID())
+	endpoint, err := url.JoinPath(dnsRESTAPIBaseURL, "ipfilters")
+	if err != nil {
+		return err
+	}
+	payload, err := generatePayload(ex, maps.Keys(ex.definedFieldsMap), nil)
+	if err != nil {
+		return err
+	}
+	payloadReader := bytes.NewReader(payload)
+	data, err := makev4APIRequest("POST", endpoint, payloadReader, 202)
+	if err != nil {
+		var details string
+		for _, item := range data {
+			details += string(item)
+		}
+		logger.Println("  unexpected response. Details: " + details)
+		return fmt.Errorf("unable to create IP filter: %s", err)
+	}
+	return nil
+}
+
+// GetIPFilters returns active IP filters
+var GetIPFilters = func() ([]*IPFilter, error) {
+	if logLevel > 0 {
+		logger.Println("Retrieving IP filters...")
+	}
+	endpoint, err := url.JoinPath(dnsRESTAPIBaseURL, "ipfilters")
+	if err != nil {
+		return nil, err
+	}
+	data, err := makev4APIRequest("GET", endpoint, nil, 200)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve IP filters: %s", err)
+	}
+
+	ipFilters := make([]*IPFilter, 0)
+	for _, item := range data {
+		var tmpIPFilters []*IPFilter
+		err = json.Unmarshal(item, &tmpIPFilters)
+		if err != nil {
+			return nil, err
+		}
+		if len(tmpIPFilters) > 0 {
+			ipFilters = append(ipFilters, tmpIPFilters...)
+		}
+	}
+	return ipFilters, nil
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestIPFilter_UnmarshalJSON(t *testing.T) {
+	data := `{"id":3,"name":"eu-only","rulesLimit":100,"continents":["EU"],"countries":["GB"],"asn":[64496],"ipv4":["10.0.0.0/8"],"ipv6":[],"regions":[{"continent":"NA","country":"US","region":"CA"}]}`
+	var obj IPFilter
+	err := json.Unmarshal([]byte(data), &obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := IPFilter{
+		ID:         3,
+		Name:       "eu-only",
+		RulesLimit: 100,
+		Continents: []string{"EU"},
+		Countries:  []string{"GB"},
+		Regions:    []*IPFilterRegion{{Continent: "NA", Country: "US", Region: "CA"}},
+		ASN:        []int{64496},
+		IPv4:       []string{"10.0.0.0/8"},
+		IPv6:       []string{},
+	}
+	if !reflect.DeepEqual(obj, expected) {
+		t.Errorf("expected %v, got %v", expected, obj)
+	}
+}
+
+func TestExpectedIPFilter_Validate(t *testing.T) {
+	tests := []struct {
+		data     string
+		expected string
+	}{
+		{`{name: eu, continents: [EU], countries: [GB], asn: [64496], ipv4: [10.0.0.0/8], ipv6: ["2001:db8::/32"]}`, ""},
+		{`{continents: [EU]}`, `: mandatory field "name" is not defined`},
+		{`{name: eu, continents: [XX]}`, `eu: unsupported continent "XX"`},
+		{`{name: eu, countries: [gb]}`, `eu: invalid country "gb", expected ISO 3166-1 alpha-2 code`},
+		{`{name: eu, regions: [{continent: NA, country: USA, region: CA}]}`, `eu: invalid country "USA" in region`},
+		{`{name: eu, asn: [-1]}`, "eu: ASN must be in range 0-4294967295, got -1"},
+		{`{name: eu, ipv4: ["2001:db8::/32"]}`, `eu: invalid IPv4 network "2001:db8::/32"`},
+		{`{name: eu, ipv6: [10.0.0.0/8]}`, `eu: invalid IPv6 network "10.0.0.0/8"`},
+	}
+	for _, test := range tests {
+		var obj ExpectedIPFilter
+		err := yaml.Unmarshal([]byte(test.data), &obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = obj.Validate()
+		if test.expected == "" {
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			continue
+		}
+		if err == nil || !strings.HasPrefix(err.Error(), test.expected) {
+			t.Errorf("expected error %q, got %v", test.expected, err)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	yaml "gopkg.in/yaml.v3"
+)
+
+var poolResourceIDTemplate = "%s %q"
+
+// Types of records which can be served by pools
+var supportedPoolTypes = []string{"A", "AAAA", "CNAME"}
+
+// Pool is a set of values which are returned for records in pools mode.
+// Missing fields: handicap, ipfilter, contacts
+type Pool struct {
+	ID   int    `json:"id"`
+	Name string `json:"name" yaml:"name"`
+	Type string `json:"type" yaml:"type"`
+	// Number of values to return
+	Return int `json:"return" yaml:"return"`
+	// Minimum number of available values, the pool fails otherwise
+	MinimumFailover int          `json:"minimumFailover" yaml:"minimumFailover"`
+	Enabled         bool         `json:"enabled" yaml:"enabled"`
+	Values          []*PoolValue `json:"values" yaml:"values"`
+}
+
+// PoolValue is a member of the pool
+type PoolValue struct {
+	Value   string `json:"value" yaml:"value"`
+	Weight  int    `json:"weight" yaml:"weight"`
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	// One of followsonar, alwaysoff, alwayson, offonfailure
+	Policy       string `json:"policy" yaml:"policy"`
+	SonarCheckID int    `json:"sonarCheckId" yaml:"sonarCheckId"`
+}
+
+// UnmarshalYAML resolves Sonar check of the pool member. It can be an integer
+// or a string `@sonar,http:Name`, the host of the check is used as the value
+// if the value is not defined
+func (v *PoolValue) UnmarshalYAML(value *yaml.Node) error {
+	var s struct {
+		Value        string      `yaml:"value"`
+		Weight       int         `yaml:"weight"`
+		Enabled      bool        `yaml:"enabled"`
+		Policy       string      `yaml:"policy"`
+		SonarCheckID interface{} `yaml:"sonarCheckId"`
+	}
+	err := value.Decode(&s)
+	if err != nil {
+		return err
+	}
+	sonarCheckID, sonarCheckHost, err := getSonarCheckID(s.SonarCheckID)
+	if err != nil {
+		return err
+	}
+	if s.Value == "" {
+		s.Value = sonarCheckHost
+	}
+	*v = PoolValue{
+		Value:        s.Value,
+		Weight:       s.Weight,
+		Enabled:      s.Enabled,
+		Policy:       s.Policy,
+		SonarCheckID: sonarCheckID,
+	}
+	return nil
+}
+
+func (ac *Pool) GetResource() interface{} {
+	return ac
+}
+
+func (ac *Pool) GetResourceID() string {
+	return fmt.Sprintf(poolResourceIDTemplate, ac.Type, ac.Name)
+}
+
+func (ac *Pool) GetConstellixID() int {
+	return ac.ID
+}
+
+func (ac *Pool) SyncResourceDelete(constellixID int) error {
+	logger.Printf("  removing resource %q\n", ac.GetResourceID())
+	endpoint, err := url.JoinPath(dnsRESTAPIBaseURL, "pools", ac.Type, fmt.Sprint(constellixID))
+	if err != nil {
+		return err
+	}
+	data, err := makev4APIRequest("DELETE", endpoint, nil, 204)
+	if err != nil {
+		var details string
+		for _, item := range data {
+			details += string(item)
+		}
+		logger.Println("  unexpected response. Details: " + details)
+		return fmt.Errorf("unable to delete pool: %s", err)
+	}
+	return nil
+}
+
+type ExpectedPool struct {
+	// Mapping of defined fields from parsed data to struct Field Names
+	definedFieldsMap map[string]string
+	// List of immutable fields which can't be updated via API
+	immutableFields []string
+	// List of mandatory fields which must be defined, used for validation
+	mandatoryFields []string
+	// Location of the resource in configuration files
+	source string
+	Pool
+}
+
+// UnmarshalYAML unmarshals the mesage and stores original fields
+func (ex *ExpectedPool) UnmarshalYAML(value *yaml.Node) error {
+	ex.immutableFields = []string{"type"}
+	ex.mandatoryFields = []string{"name", "type", "values"}
+
+	// Unmarshall data into Pool struct
+	var s Pool
+	err := value.Decode(&s)
+	if err != nil {
+		return err
+	}
+	ex.Pool = s
+
+	// Save specified fields
+	dm := make(map[string]interface{})
+	err = value.Decode(&dm)
+	if err != nil {
+		return err
+	}
+
+	definedFields := make([]string, len(dm))
+	i := 0
+	for k := range dm {
+		definedFields[i] = k
+		i++
+	}
+	ex.definedFieldsMap = getFieldNamesMap(&ex.Pool, "yaml", definedFields...)
+	return nil
+}
+
+// Validate performs simple validation of user provided data
+func (ex *ExpectedPool) Validate() error {
+	// Validate that all mandatory fields are present
+	for _, f := range ex.mandatoryFields {
+		if !slices.Contains(maps.Keys(ex.definedFieldsMap), f) {
+			return fmt.Errorf("%s: mandatory field %q is not defined", ex.Name, f)
+		}
+	}
+	if !slices.Contains(supportedPoolTypes, ex.Type) {
+		return fmt.Errorf("%s: unsupported pool type %q, expected one of %q", ex.Name, ex.Type, supportedPoolTypes)
+	}
+	if ex.MinimumFailover > len(ex.Values) {
+		return fmt.Errorf(
+			"%s: minimumFailover %d is greater than the number of values %d", ex.Name, ex.MinimumFailover, len(ex.Values),
+		)
+	}
+	return nil
+}
+
+// GetSource returns location of the resource in configuration files
+func (ex *ExpectedPool) GetSource() string {
+	return ex.source
+}
+
+// SetSource sets location of the resource in configuration files
+func (ex *ExpectedPool) SetSource(source string) {
+	ex.source = source
+}
+
+// GetDefinedStructFieldNames returns list of defined struct fields from local configuration
+func (ex *ExpectedPool) GetDefinedStructFieldNames() []string {
+	return maps.Values(ex.definedFieldsMap)
+}
+
+// GetImmutableStructFields returns list of immutable struct fields
+func (ex *ExpectedPool) GetImmutableStructFields() []string {
+	var imf []string
+	for k, v := range ex.definedFieldsMap {
+		if slices.Contains(ex.immutableFields, k) {
+			imf = append(imf, v)
+		}
+	}
+	return imf
+}
+
+func (ex *ExpectedPool) GetResource() interface{} {
+	return ex.Pool
+}
+
+func (ex *ExpectedPool) GetResourceID() string {
+	return fmt.Sprintf(poolResourceIDTemplate, ex.Type, ex.Name)
+}
+
+func (ex *ExpectedPool) SyncResourceUpdate(constellixID int) error {
+	logger.Printf("  updating resource %q\n", ex.GetResourceID())
+	endpoint, err := url.JoinPath(dnsRESTAPIBaseURL, "pools", ex.Type, fmt.Sprint(constellixID))
+	if err != nil {
+		return err
+	}
+	payload, err := generatePayload(ex, maps.Keys(ex.definedFieldsMap), nil)
+	if err != nil {
+		return err
+	}
+	payloadReader := bytes.NewReader(payload)
+	data, err := makev4APIRequest("PUT", endpoint, payloadReader, 200)
+	if err != nil {
+		var details string
+		for _, item := range data {
+			details += string(item)
+		}
+		logger.Println("  unexpected response. Details: " + details)
+		return fmt.Errorf("unable to update pool: %s", err)
+	}
+	return nil
+}
+
+func (ex *ExpectedPool) SyncResourceCreate() error {
+	logger.Printf("  creating new resource %q\n", ex.GetResourceID())
+	endpoint, err := url.JoinPath(dnsRESTAPIBaseURL, "pools")
+	if err != nil {
+		return err
+	}
+	payload, err := generatePayload(ex, maps.Keys(ex.definedFieldsMap), nil)
+	if err != nil {
+		return err
+	}
+	payloadReader := bytes.NewReader(payload)
+	data, err := makev4APIRequest("POST", endpoint, payloadReader, 202)
+	if err != nil {
+		var details string
+		for _, item := range data {
+			details += string(item)
+		}
+		logger.Println("  unexpected response. Details: " + details)
+		return fmt.Errorf("unable to create pool: %s", err)
+	}
+	return nil
+}
+
+// GetPools returns active pools of all types
+var GetPools = func() ([]*Pool, error) {
+	if logLevel > 0 {
+		logger.Println("Retrieving pools...")
+	}
+	endpoint, err := url.JoinPath(dnsRESTAPIBaseURL, "pools")
+	if err != nil {
+		return nil, err
+	}
+	data, err := makev4APIRequest("GET", endpoint, nil, 200)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve pools: %s", err)
+	}
+
+	pools := make([]*Pool, 0)
+	for _, item := range data {
+		var tmpPools []*Pool
+		err = json.Unmarshal(item, &tmpPools)
+		if err != nil {
+			return nil, err
+		}
+		if len(tmpPools) > 0 {
+			pools = append(pools, tmpPools...)
+		}
+	}
+	return pools, nil
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestPool_UnmarshalJSON(t *testing.T) {
+	data := `{"id":7,"name":"web-eu","type":"A","return":1,"minimumFailover":1,"failed":false,"enabled":true,"values":[{"value":"1.1.1.1","weight":10,"enabled":true,"handicap":0,"policy":"followsonar","sonarCheckId":84874},{"value":"2.2.2.2","weight":20,"enabled":true,"handicap":0,"policy":"alwayson","sonarCheckId":null}]}`
+	var obj Pool
+	err := json.Unmarshal([]byte(data), &obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Pool{
+		ID:              7,
+		Name:            "web-eu",
+		Type:            "A",
+		Return:          1,
+		MinimumFailover: 1,
+		Enabled:         true,
+		Values: []*PoolValue{
+			{Value: "1.1.1.1", Weight: 10, Enabled: true, Policy: "followsonar", SonarCheckID: 84874},
+			{Value: "2.2.2.2", Weight: 20, Enabled: true, Policy: "alwayson"},
+		},
+	}
+	if !reflect.DeepEqual(obj, expected) {
+		t.Errorf("expected %v, got %v", expected, obj)
+	}
+}
+
+func TestExpectedPool_UnmarshalYAML(t *testing.T) {
+	data := `
+name: web-eu
+type: A
+minimumFailover: 1
+values:
+  - value: 1.1.1.1
+    weight: 10
+    enabled: true
+    policy: followsonar
+    sonarCheckId: 84874
+`
+	var obj ExpectedPool
+	err := yaml.Unmarshal([]byte(data), &obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = obj.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*PoolValue{
+		{Value: "1.1.1.1", Weight: 10, Enabled: true, Policy: "followsonar", SonarCheckID: 84874},
+	}
+	if !reflect.DeepEqual(obj.Values, expected) {
+		t.Errorf("expected %v, got %v", expected, obj.Values)
+	}
+	if obj.GetResourceID() != `A "web-eu"` {
+		t.Errorf("unexpected resource ID %s", obj.GetResourceID())
+	}
+	definedFields := obj.GetDefinedStructFieldNames()
+	if len(definedFields) != 4 {
+		t.Errorf("expected 4 defined fields, got %v", definedFields)
+	}
+}
+
+func TestExpectedPool_Validate(t *testing.T) {
+	tests := []struct {
+		data     string
+		expected string
+	}{
+		{`{name: web, type: A}`, `web: mandatory field "values" is not defined`},
+		{`{name: web, type: MX, values: []}`, `web: unsupported pool type "MX", expected one of ["A" "AAAA" "CNAME"]`},
+		{`{name: web, type: A, minimumFailover: 2, values: [{value: 1.1.1.1}]}`, "web: minimumFailover 2 is greater than the number of values 1"},
+	}
+	for _, test := range tests {
+		var obj ExpectedPool
+		err := yaml.Unmarshal([]byte(test.data), &obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = obj.Validate()
+		if err == nil || !strings.HasPrefix(err.Error(), test.expected) {
+			t.Errorf("expected error %q, got %v", test.expected, err)
+		}
+	}
+}
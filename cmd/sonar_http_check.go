@@ -107,6 +107,8 @@ type ExpectedSonarHTTPCheck struct {
 	immutableFields []string
 	// List of mandatory fields which must be defined, used for validation
 	mandatoryFields []string
+	// Location of the resource in configuration files
+	source string
 	SonarHTTPCheck
 }
 
@@ -151,6 +153,16 @@ func (ex *ExpectedSonarHTTPCheck) Validate() error {
 	return nil
 }
 
+// GetSource returns location of the resource in configuration files
+func (ex *ExpectedSonarHTTPCheck) GetSource() string {
+	return ex.source
+}
+
+// SetSource sets location of the resource in configuration files
+func (ex *ExpectedSonarHTTPCheck) SetSource(source string) {
+	ex.source = source
+}
+
 // GetDefinedStructFieldNames returns list of defined struct fields from local configuration
 func (ex *ExpectedSonarHTTPCheck) GetDefinedStructFieldNames() []string {
 	return maps.Values(ex.definedFieldsMap)
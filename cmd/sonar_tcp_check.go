@@ -87,6 +87,8 @@ type ExpectedSonarTCPCheck struct {
 	immutableFields []string
 	// List of mandatory fields which must be defined, used for validation
 	mandatoryFields []string
+	// Location of the resource in configuration files
+	source string
 	SonarTCPCheck
 }
 
@@ -131,6 +133,16 @@ func (ex *ExpectedSonarTCPCheck) Validate() error {
 	return nil
 }
 
+// GetSource returns location of the resource in configuration files
+func (ex *ExpectedSonarTCPCheck) GetSource() string {
+	return ex.source
+}
+
+// SetSource sets location of the resource in configuration files
+func (ex *ExpectedSonarTCPCheck) SetSource(source string) {
+	ex.source = source
+}
+
 // GetDefinedStructFieldNames returns list of defined struct fields from local configuration
 func (ex *ExpectedSonarTCPCheck) GetDefinedStructFieldNames() []string {
 	return maps.Values(ex.definedFieldsMap)
@@ -35,6 +35,18 @@ func Sync(expectedCollection, activeCollection []ResourceMatcher, doit, remove b
 		if logLevel > 0 {
 			fmt.Printf("Inspecting %q...\n", activeResource.GetResourceID())
 		}
+		if unmanaged, ok := a.(IUnmanagedResource); ok && unmanaged.IsUnmanaged() && !unmanaged.IsRemovable() {
+			if logLevel > 0 {
+				fmt.Printf("  status: %s\n", ActionUnmanaged)
+			}
+			report.AppendRow(table.Row{
+				colorAction(ActionUnmanaged),
+				activeResource.GetResourceID(),
+				fmt.Sprintf("Resource ID %d", activeResource.GetConstellixID()),
+			})
+			report.AppendSeparator()
+			continue
+		}
 		matched := getMatchingResource(activeResource, expectedCollection)
 		if matched == nil {
 			if logLevel > 0 {
@@ -574,3 +574,31 @@ func Test_Generate_payload_geoproximity_empty(t *testing.T) {
 		return
 	}
 }
+
+func Test_Sync_unmanaged_doit_remove(t *testing.T) {
+	ar := &DNSRecord{
+		ID:     999,
+		Name:   "_25._tcp",
+		Type:   "TLSA",
+		Region: "default",
+		raw:    []byte(`{"id":999}`),
+	}
+	actCol := toResourceMatcher([]*DNSRecord{ar})
+
+	reportToTestBuffer = true
+	defer func() {
+		reportToTestBuffer = false
+		testBuffer.Reset()
+	}()
+	// Removing is not allowed for the record, it must not be deleted
+	err := Sync(nil, actCol, true, true, "")
+
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	output := stripBashColors(testBuffer.String())
+	expected := "unmanaged,\"TLSA \\\"_25._tcp\\\" (default\\, 0)\",Resource ID 999\n"
+	if output != expected {
+		t.Errorf("want %q, got %q", expected, output)
+	}
+}
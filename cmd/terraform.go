@@ -0,0 +1,464 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/exp/slices"
+)
+
+// Export of the resolved configuration as resources of Constellix Terraform
+// provider. Sonar checks, geoproximities and domains which are defined in the
+// configuration are referenced by records, other resources are referenced by
+// their Constellix IDs
+
+// terraformLookup maps Constellix IDs from the resolved configuration back to
+// the names of resources
+type terraformLookup struct {
+	HTTPChecks     map[int]string
+	TCPChecks      map[int]string
+	GeoProximities map[int]string
+	IPFilters      map[int]string
+}
+
+// hclExpr is an expression which is rendered as is, e.g. a reference
+type hclExpr string
+
+// Failover types of Terraform provider
+var terraformFailoverTypes = map[string]int{"normal": 1, "off": 2, "one-way": 3}
+
+// Record options of Terraform provider
+var terraformRecordOptions = map[string]string{
+	"standard":            "roundRobin",
+	"failover":            "failover",
+	"roundrobin-failover": "roundRobinFailover",
+	"pools":               "pools",
+}
+
+var terraformNameRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// hclWriter renders HCL blocks and attributes
+type hclWriter struct {
+	sb     strings.Builder
+	indent int
+	// Resource names already used, per resource type
+	names map[string]map[string]bool
+}
+
+func (h *hclWriter) line(format string, args ...interface{}) {
+	h.sb.WriteString(strings.Repeat("  ", h.indent))
+	fmt.Fprintf(&h.sb, format, args...)
+	h.sb.WriteString("\n")
+}
+
+func (h *hclWriter) openBlock(header string) {
+	h.line("%s {", header)
+	h.indent++
+}
+
+func (h *hclWriter) closeBlock() {
+	h.indent--
+	h.line("}")
+}
+
+func (h *hclWriter) comment(text string) {
+	h.line("# %s", text)
+}
+
+func (h *hclWriter) attr(name string, value interface{}) {
+	h.line("%s = %s", name, hclValue(value))
+}
+
+// resourceName returns unique name of the resource among resources of the type
+func (h *hclWriter) resourceName(resourceType string, parts ...string) string {
+	name := terraformName(parts...)
+	if h.names[resourceType] == nil {
+		h.names[resourceType] = make(map[string]bool)
+	}
+	unique := name
+	for i := 2; h.names[resourceType][unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", name, i)
+	}
+	h.names[resourceType][unique] = true
+	return unique
+}
+
+// terraformName converts parts into a valid Terraform identifier
+func terraformName(parts ...string) string {
+	var nonEmpty []string
+	for _, part := range parts {
+		part = terraformNameRe.ReplaceAllString(strings.ToLower(part), "_")
+		part = strings.Trim(part, "_")
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	name := strings.Join(nonEmpty, "_")
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "_" + name
+	}
+	return name
+}
+
+// hclValue renders the value as HCL expression
+func hclValue(value interface{}) string {
+	switch v := value.(type) {
+	case hclExpr:
+		return string(v)
+	case string:
+		s := strings.ReplaceAll(v, `\`, `\\`)
+		s = strings.ReplaceAll(s, `"`, `\"`)
+		s = strings.ReplaceAll(s, "\n", `\n`)
+		s = strings.ReplaceAll(s, "${", "$${")
+		s = strings.ReplaceAll(s, "%{", "%%{")
+		return `"` + s + `"`
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int, bool:
+		return fmt.Sprint(v)
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Slice {
+		items := make([]string, rv.Len())
+		for i := range items {
+			items[i] = hclValue(rv.Index(i).Interface())
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return hclValue(fmt.Sprint(value))
+}
+
+// toSnakeCase converts camelCase field names of the configuration into
+// attribute names of Terraform provider
+func toSnakeCase(s string) string {
+	var sb strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// structAttrs renders fields of the struct which have non-zero values, names
+// of attributes are derived from yaml tags
+func (h *hclWriter) structAttrs(v interface{}, skip ...string) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		tag := strings.Split(rt.Field(i).Tag.Get("yaml"), ",")[0]
+		if tag == "" || tag == "-" || rv.Field(i).IsZero() {
+			continue
+		}
+		skipped := false
+		for _, s := range skip {
+			if s == tag {
+				skipped = true
+			}
+		}
+		if !skipped {
+			h.attr(toSnakeCase(tag), rv.Field(i).Interface())
+		}
+	}
+}
+
+// exportTerraform renders the configuration as HCL
+func exportTerraform(w io.Writer, config *Config, lookup *terraformLookup) error {
+	h := &hclWriter{names: make(map[string]map[string]bool)}
+
+	// Names of Terraform resources by names of mech resources
+	httpChecks := make(map[string]string)
+	tcpChecks := make(map[string]string)
+	geoProximities := make(map[string]string)
+	domains := make(map[string]string)
+
+	for _, check := range config.SonarHTTPChecks {
+		name := h.resourceName("constellix_http_check", check.Name)
+		httpChecks[check.Name] = name
+		h.openBlock(fmt.Sprintf("resource %q %q", "constellix_http_check", name))
+		h.structAttrs(&check.SonarHTTPCheck, "userId")
+		h.closeBlock()
+		h.line("")
+	}
+	for _, check := range config.SonarTCPChecks {
+		name := h.resourceName("constellix_tcp_check", check.Name)
+		tcpChecks[check.Name] = name
+		h.openBlock(fmt.Sprintf("resource %q %q", "constellix_tcp_check", name))
+		h.structAttrs(&check.SonarTCPCheck, "userId")
+		h.closeBlock()
+		h.line("")
+	}
+	for _, gp := range config.GeoProximities {
+		name := h.resourceName("constellix_geo_proximity", gp.Name)
+		geoProximities[gp.Name] = name
+		h.openBlock(fmt.Sprintf("resource %q %q", "constellix_geo_proximity", name))
+		h.structAttrs(&gp.GeoProximity)
+		h.closeBlock()
+		h.line("")
+	}
+	for _, domain := range config.Domains {
+		name := h.resourceName("constellix_domain", domain.Name)
+		domains[domain.Name] = "constellix_domain." + name + ".id"
+		h.openBlock(fmt.Sprintf("resource %q %q", "constellix_domain", name))
+		writeTerraformDomain(h, &domain.DNSDomain)
+		h.closeBlock()
+		h.line("")
+	}
+
+	domainNames := make([]string, 0, len(config.DNS))
+	for domainName := range config.DNS {
+		domainNames = append(domainNames, domainName)
+	}
+	sort.Strings(domainNames)
+	for _, domainName := range domainNames {
+		if _, ok := domains[domainName]; ok {
+			continue
+		}
+		// Settings of the domain are not managed, it is looked up by name
+		name := h.resourceName("data.constellix_domain", domainName)
+		domains[domainName] = "data.constellix_domain." + name + ".id"
+		h.openBlock(fmt.Sprintf("data %q %q", "constellix_domain", name))
+		h.attr("name", domainName)
+		h.closeBlock()
+		h.line("")
+	}
+
+	checkRef := func(id int) interface{} {
+		httpCheck, isHTTP := lookup.HTTPChecks[id]
+		tcpCheck, isTCP := lookup.TCPChecks[id]
+		// HTTP and TCP checks have separate IDs and the value of the record
+		// doesn't define the type of the check
+		if isHTTP && isTCP {
+			h.comment(fmt.Sprintf("Sonar check %d is either HTTP check %s or TCP check %s, referenced by ID", id, httpCheck, tcpCheck))
+			return id
+		}
+		if name, ok := httpChecks[httpCheck]; isHTTP && ok {
+			return hclExpr("constellix_http_check." + name + ".id")
+		}
+		if name, ok := tcpChecks[tcpCheck]; isTCP && ok {
+			return hclExpr("constellix_tcp_check." + name + ".id")
+		}
+		return id
+	}
+	geoProximityRef := func(id int) interface{} {
+		if name, ok := geoProximities[lookup.GeoProximities[id]]; ok {
+			return hclExpr("constellix_geo_proximity." + name + ".id")
+		}
+		return id
+	}
+
+	for _, domainName := range domainNames {
+		for _, record := range config.DNS[domainName] {
+			resourceType := "constellix_" + strings.ToLower(record.Type) + "_record"
+			if record.Type == "HTTP" {
+				resourceType = "constellix_http_redirection_record"
+			}
+			recordName := strings.ReplaceAll(record.Name, "*", "wildcard")
+			if recordName == "" {
+				recordName = "apex"
+			}
+			var region string
+			if record.Region != "" && record.Region != "default" {
+				region = record.Region
+			}
+			name := h.resourceName(resourceType, domainName, recordName, region)
+			h.openBlock(fmt.Sprintf("resource %q %q", resourceType, name))
+			writeTerraformRecord(h, record, hclExpr(domains[domainName]), lookup, checkRef, geoProximityRef)
+			h.closeBlock()
+			h.line("")
+		}
+	}
+
+	_, err := io.WriteString(w, strings.TrimSuffix(h.sb.String(), "\n"))
+	return err
+}
+
+func writeTerraformDomain(h *hclWriter, domain *DNSDomain) {
+	h.attr("name", domain.Name)
+	if domain.Note != "" {
+		h.attr("note", domain.Note)
+	}
+	h.attr("has_gtd_regions", domain.GTDEnabled)
+	h.attr("has_geoip", domain.GeoIPEnabled)
+	if len(domain.Tags) > 0 {
+		h.attr("tags", domain.Tags)
+	}
+	if domain.Template != 0 {
+		h.attr("template", domain.Template)
+	}
+	if domain.VanityNameserver != nil {
+		h.attr("vanity_nameserver", domain.VanityNameserver)
+	}
+	if soa := domain.SOA; soa != nil {
+		h.openBlock("soa =")
+		h.attr("primary_nameserver", soa.PrimaryNameserver)
+		h.attr("email", soa.Email)
+		h.attr("ttl", soa.TTL)
+		h.attr("refresh", soa.Refresh)
+		h.attr("retry", soa.Retry)
+		h.attr("expire", soa.Expire)
+		h.attr("negcache", soa.NegativeCache)
+		h.closeBlock()
+	}
+}
+
+func writeTerraformRecord(
+	h *hclWriter, record *ExpectedDNSRecord, domainRef hclExpr, lookup *terraformLookup,
+	checkRef, geoProximityRef func(int) interface{},
+) {
+	h.attr("domain_id", domainRef)
+	h.attr("source_type", "domains")
+	h.attr("name", record.Name)
+	h.attr("ttl", record.TTL)
+	if record.Notes != "" {
+		h.attr("note", record.Notes)
+	}
+	// Records are enabled unless it's defined otherwise
+	if slices.Contains(record.GetDefinedStructFieldNames(), "Enabled") && !record.Enabled {
+		h.attr("noanswer", true)
+	}
+	if record.Region != "" && record.Region != "default" {
+		h.comment(fmt.Sprintf("GTD region %s: set gtd_region to the ID of the region", record.Region))
+	}
+	if contacts, ok := record.Contacts.([]int); ok && len(contacts) > 0 {
+		h.attr("contact_ids", contacts)
+	}
+	if record.IPFilter != nil {
+		// Terraform provider doesn't support IP filters
+		ipFilter, ok := lookup.IPFilters[toInt(record.IPFilter)]
+		if !ok {
+			ipFilter = fmt.Sprint(record.IPFilter)
+		}
+		h.comment(fmt.Sprintf("ip filter %s not exported", ipFilter))
+	}
+	if record.GeoProximity != nil {
+		h.openBlock("geo_location")
+		h.attr("geo_ip_proximity", geoProximityRef(toInt(record.GeoProximity)))
+		h.closeBlock()
+	}
+	if option, ok := terraformRecordOptions[record.Mode]; ok && record.Type != "HTTP" {
+		h.attr("record_option", option)
+	}
+
+	switch v := record.Value.(type) {
+	case []*DNSStandardItemValue:
+		if record.Type == "CNAME" {
+			// CNAME has a single value
+			for _, item := range v {
+				h.attr("host", item.Value)
+			}
+			return
+		}
+		for _, item := range v {
+			h.openBlock("roundrobin")
+			h.attr("value", item.Value)
+			h.attr("disable_flag", !item.Enabled)
+			h.closeBlock()
+		}
+	case []*DNSMXStandardItemValue:
+		for _, item := range v {
+			h.openBlock("roundrobin")
+			h.attr("value", item.Server)
+			h.attr("level", item.Priority)
+			h.attr("disable_flag", !item.Enabled)
+			h.closeBlock()
+		}
+	case []*DNSSRVStandardItemValue:
+		for _, item := range v {
+			h.openBlock("roundrobin")
+			h.attr("value", item.Host)
+			h.attr("port", item.Port)
+			h.attr("priority", item.Priority)
+			h.attr("weight", item.Weight)
+			h.attr("disable_flag", !item.Enabled)
+			h.closeBlock()
+		}
+	case []*DNSCAAStandardItemValue:
+		for _, item := range v {
+			h.openBlock("roundrobin")
+			h.attr("tag", item.Tag)
+			h.attr("data", item.Data)
+			h.attr("flag", strconv.Itoa(item.Flags))
+			h.attr("disable_flag", !item.Enabled)
+			h.closeBlock()
+		}
+	case []*DNSHINFOStandardItemValue, []*DNSRPStandardItemValue, []*DNSCERTStandardItemValue, []*DNSNAPTRStandardItemValue:
+		rv := reflect.ValueOf(v)
+		for i := 0; i < rv.Len(); i++ {
+			item := rv.Index(i)
+			h.openBlock("roundrobin")
+			h.structAttrs(item.Interface(), "enabled")
+			h.attr("disable_flag", !item.Elem().FieldByName("Enabled").Bool())
+			h.closeBlock()
+		}
+	case *DNSHTTPStandardItemValue:
+		h.structAttrs(v)
+	case *DNSFailoverValue:
+		h.openBlock("record_failover")
+		h.attr("failover_type", terraformFailoverTypes[v.Mode])
+		h.attr("disable_flag", !v.Enabled)
+		for _, item := range v.Values {
+			h.openBlock("values")
+			h.attr("value", item.Value)
+			h.attr("sort_order", item.Order)
+			if item.SonarCheckID != 0 {
+				h.attr("check_id", checkRef(item.SonarCheckID))
+			}
+			h.attr("disable_flag", !item.Enabled)
+			h.closeBlock()
+		}
+		h.closeBlock()
+	case *DNSMXFailoverValue:
+		h.openBlock("record_failover")
+		h.attr("failover_type", terraformFailoverTypes[v.Mode])
+		h.attr("disable_flag", !v.Enabled)
+		for _, item := range v.Values {
+			h.openBlock("values")
+			h.attr("value", item.Server)
+			h.attr("level", item.Priority)
+			h.attr("sort_order", item.Order)
+			if item.SonarCheckID != 0 {
+				h.attr("check_id", checkRef(item.SonarCheckID))
+			}
+			h.attr("disable_flag", !item.Enabled)
+			h.closeBlock()
+		}
+		h.closeBlock()
+	case []*DNSFailoverItemValue:
+		for _, item := range v {
+			h.openBlock("roundrobin_failover")
+			h.attr("value", item.Value)
+			h.attr("sort_order", item.Order)
+			if item.SonarCheckID != 0 {
+				h.attr("check_id", checkRef(item.SonarCheckID))
+			}
+			h.attr("disable_flag", !item.Enabled)
+			h.closeBlock()
+		}
+	case []*DNSMXFailoverItemValue:
+		for _, item := range v {
+			h.openBlock("roundrobin_failover")
+			h.attr("value", item.Server)
+			h.attr("level", item.Priority)
+			h.attr("sort_order", item.Order)
+			if item.SonarCheckID != 0 {
+				h.attr("check_id", checkRef(item.SonarCheckID))
+			}
+			h.attr("disable_flag", !item.Enabled)
+			h.closeBlock()
+		}
+	case []int:
+		h.comment("pools are not exported, referenced by ID")
+		h.attr("pools", v)
+	}
+}
@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExportTerraform(t *testing.T) {
+	configFile := writeTestConfig(t, map[string]string{
+		"main.yaml": `
+constellix:
+  sonar:
+    http_checks:
+      - checks.yaml
+    tcp_checks:
+      - tcp-checks.yaml
+  geoproximity:
+    - geo.yaml
+  domains:
+    - domains.yaml
+  dns:
+    example.com:
+      - example.yaml
+    example.org:
+      - example-org.yaml
+`,
+		"checks.yaml": `
+- name: web-1
+  host: 192.0.2.1
+  port: 443
+  protocolType: HTTPS
+  ipVersion: IPV4
+  interval: ONEMINUTE
+  checkSites: [1, 2]
+`,
+		"tcp-checks.yaml": `
+- name: db-1
+  host: 192.0.2.3
+  port: 5432
+  ipVersion: IPV4
+  interval: ONEMINUTE
+  checkSites: [1]
+`,
+		"geo.yaml": `
+- name: amsterdam
+  latitude: 52.37
+  longitude: 4.89
+`,
+		"domains.yaml": `
+- name: example.com
+  gtd: true
+  soa:
+    primaryNameserver: ns11.constellix.com.
+    email: dns.constellix.com.
+    ttl: 86400
+    refresh: 86400
+    retry: 7200
+    expire: 3600000
+    negativeCache: 180
+`,
+		"example.yaml": `
+- name: www
+  type: A
+  ttl: 60
+  mode: failover
+  value:
+    mode: normal
+    enabled: true
+    values:
+      - value: 192.0.2.1
+        order: 1
+        sonarCheckId: 101
+        enabled: true
+      - value: 192.0.2.2
+        order: 2
+        sonarCheckId: 999
+        enabled: true
+      - value: 192.0.2.3
+        order: 3
+        sonarCheckId: 202
+        enabled: true
+      - value: 192.0.2.4
+        order: 4
+        sonarCheckId: 303
+        enabled: true
+- name: ""
+  type: MX
+  ttl: 3600
+  mode: standard
+  region: europe
+  geoproximity: 7
+  ipfilter: 3
+  value:
+    - server: mail.example.com.
+      priority: 10
+      enabled: true
+`,
+		"example-org.yaml": `
+- name: ""
+  type: TXT
+  ttl: 300
+  mode: standard
+  enabled: false
+  value:
+    - value: '"v=spf1 ${x} -all"'
+      enabled: false
+`,
+	})
+	config, err := getConfig(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookup := &terraformLookup{
+		HTTPChecks:     map[int]string{101: "web-1", 303: "legacy"},
+		TCPChecks:      map[int]string{202: "db-1", 303: "db-2"},
+		GeoProximities: map[int]string{7: "amsterdam"},
+		IPFilters:      map[int]string{3: "eu-only"},
+	}
+	var sb strings.Builder
+	err = exportTerraform(&sb, config, lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `resource "constellix_http_check" "web_1" {
+  name = "web-1"
+  host = "192.0.2.1"
+  ip_version = "IPV4"
+  port = 443
+  protocol_type = "HTTPS"
+  interval = "ONEMINUTE"
+  check_sites = [1, 2]
+}
+
+resource "constellix_tcp_check" "db_1" {
+  name = "db-1"
+  host = "192.0.2.3"
+  ip_version = "IPV4"
+  port = 5432
+  interval = "ONEMINUTE"
+  check_sites = [1]
+}
+
+resource "constellix_geo_proximity" "amsterdam" {
+  name = "amsterdam"
+  longitude = 4.89
+  latitude = 52.37
+}
+
+resource "constellix_domain" "example_com" {
+  name = "example.com"
+  has_gtd_regions = true
+  has_geoip = false
+  soa = {
+    primary_nameserver = "ns11.constellix.com."
+    email = "dns.constellix.com."
+    ttl = 86400
+    refresh = 86400
+    retry = 7200
+    expire = 3600000
+    negcache = 180
+  }
+}
+
+data "constellix_domain" "example_org" {
+  name = "example.org"
+}
+
+resource "constellix_a_record" "example_com_www" {
+  domain_id = constellix_domain.example_com.id
+  source_type = "domains"
+  name = "www"
+  ttl = 60
+  record_option = "failover"
+  record_failover {
+    failover_type = 1
+    disable_flag = false
+    values {
+      value = "192.0.2.1"
+      sort_order = 1
+      check_id = constellix_http_check.web_1.id
+      disable_flag = false
+    }
+    values {
+      value = "192.0.2.2"
+      sort_order = 2
+      check_id = 999
+      disable_flag = false
+    }
+    values {
+      value = "192.0.2.3"
+      sort_order = 3
+      check_id = constellix_tcp_check.db_1.id
+      disable_flag = false
+    }
+    values {
+      value = "192.0.2.4"
+      sort_order = 4
+      # Sonar check 303 is either HTTP check legacy or TCP check db-2, referenced by ID
+      check_id = 303
+      disable_flag = false
+    }
+  }
+}
+
+resource "constellix_mx_record" "example_com_apex_europe" {
+  domain_id = constellix_domain.example_com.id
+  source_type = "domains"
+  name = ""
+  ttl = 3600
+  # GTD region europe: set gtd_region to the ID of the region
+  # ip filter eu-only not exported
+  geo_location {
+    geo_ip_proximity = constellix_geo_proximity.amsterdam.id
+  }
+  record_option = "roundRobin"
+  roundrobin {
+    value = "mail.example.com."
+    level = 10
+    disable_flag = false
+  }
+}
+
+resource "constellix_txt_record" "example_org_apex" {
+  domain_id = data.constellix_domain.example_org.id
+  source_type = "domains"
+  name = ""
+  ttl = 300
+  noanswer = true
+  record_option = "roundRobin"
+  roundrobin {
+    value = "\"v=spf1 $${x} -all\""
+    disable_flag = true
+  }
+}
+`
+	if sb.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sb.String())
+	}
+}
+
+func TestTerraformName(t *testing.T) {
+	tests := map[string][]string{
+		"example_com_www": {"example.com", "www"},
+		"example_com":     {"example.com", ""},
+		"_1_example":      {"1.example"},
+		"sip_tcp":         {"_sip._tcp"},
+	}
+	for expected, parts := range tests {
+		if name := terraformName(parts...); name != expected {
+			t.Errorf("%q: expected %q, got %q", parts, expected, name)
+		}
+	}
+}
@@ -41,6 +41,8 @@ func colorAction(action ResourceAction) string {
 		start = White
 	case ActionError:
 		start = Purple
+	case ActionUnmanaged:
+		start = Gray
 	}
 	return start + string(action) + Reset
 }
@@ -56,6 +58,21 @@ func colorStatus(status ResourceRuntimeStatus) string {
 	return start + string(status) + Reset
 }
 
+func colorVerifyStatus(status DNSVerifyStatus) string {
+	var start string
+	switch status {
+	case VerifyOK:
+		start = Green
+	case VerifyMismatch:
+		start = Red
+	case VerifySkipped:
+		start = Gray
+	case VerifyError:
+		start = Purple
+	}
+	return start + string(status) + Reset
+}
+
 var colorRe = regexp.MustCompile(`\x1b\[[0-9;]*m`)
 
 func stripBashColors(s string) string {
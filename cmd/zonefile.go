@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Minimal parser of RFC 1035 zone files (master files). It supports $ORIGIN
+// and $TTL directives, parentheses, comments, quoted strings, relative names
+// and owner names inherited from the previous record. Record data is not
+// interpreted, it is returned as a list of tokens
+
+// zoneRecord is a resource record from the zone file
+type zoneRecord struct {
+	// Absolute owner name with trailing dot
+	Name  string
+	TTL   int
+	Class string
+	Type  string
+	Data  []zoneToken
+	// Origin at the moment the record was defined, used to resolve relative
+	// names in the data
+	Origin string
+	Line   int
+}
+
+// zoneToken is a single field of a zone file line
+type zoneToken struct {
+	Text   string
+	Quoted bool
+}
+
+var zoneClasses = []string{"IN", "CH", "HS", "CS"}
+
+// parseZoneFile parses the zone file. Origin is used for relative names until
+// it is redefined with $ORIGIN
+func parseZoneFile(r io.Reader, origin string) ([]*zoneRecord, error) {
+	origin = toFQDN(origin, ".")
+	var records []*zoneRecord
+	var defaultTTL, lastTTL int
+	var hasDefaultTTL bool
+	var lastName string
+
+	lines, err := readZoneLines(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		tokens := line.tokens
+		if len(tokens) == 0 {
+			continue
+		}
+		if !line.inherited && !tokens[0].Quoted && strings.HasPrefix(tokens[0].Text, "$") {
+			directive := strings.ToUpper(tokens[0].Text)
+			switch directive {
+			case "$ORIGIN":
+				if len(tokens) < 2 {
+					return nil, fmt.Errorf("line %d: $ORIGIN requires a domain name", line.number)
+				}
+				origin = toFQDN(tokens[1].Text, origin)
+			case "$TTL":
+				if len(tokens) < 2 {
+					return nil, fmt.Errorf("line %d: $TTL requires a value", line.number)
+				}
+				defaultTTL, err = parseZoneTTL(tokens[1].Text)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %s", line.number, err)
+				}
+				hasDefaultTTL = true
+			default:
+				return nil, fmt.Errorf("line %d: unsupported directive %s", line.number, tokens[0].Text)
+			}
+			continue
+		}
+
+		record := zoneRecord{Origin: origin, Line: line.number}
+		if line.inherited {
+			if lastName == "" {
+				return nil, fmt.Errorf("line %d: owner name is not defined", line.number)
+			}
+			record.Name = lastName
+		} else {
+			record.Name = toFQDN(tokens[0].Text, origin)
+			tokens = tokens[1:]
+		}
+
+		// TTL and class are optional and can be defined in any order
+		ttlDefined := false
+	FIELDS:
+		for len(tokens) > 0 {
+			field := tokens[0].Text
+			switch {
+			case slicesContainsFold(zoneClasses, field) && record.Class == "":
+				record.Class = strings.ToUpper(field)
+			case !ttlDefined && len(field) > 0 && field[0] >= '0' && field[0] <= '9':
+				record.TTL, err = parseZoneTTL(field)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %s", line.number, err)
+				}
+				ttlDefined = true
+			default:
+				break FIELDS
+			}
+			tokens = tokens[1:]
+		}
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("line %d: record type is not defined", line.number)
+		}
+		record.Type = strings.ToUpper(tokens[0].Text)
+		record.Data = tokens[1:]
+		if record.Class == "" {
+			record.Class = "IN"
+		}
+		if !ttlDefined {
+			// RFC 2308: default TTL, RFC 1035: TTL of the previous record
+			if hasDefaultTTL {
+				record.TTL = defaultTTL
+			} else {
+				record.TTL = lastTTL
+			}
+		}
+		lastName = record.Name
+		lastTTL = record.TTL
+		records = append(records, &record)
+	}
+	return records, nil
+}
+
+// zoneLine is a logical line of the zone file, parentheses can join multiple
+// physical lines
+type zoneLine struct {
+	tokens []zoneToken
+	// Line starts with a whitespace, owner name is inherited
+	inherited bool
+	number    int
+}
+
+// readZoneLines splits the zone file into logical lines of tokens
+func readZoneLines(r io.Reader) ([]*zoneLine, error) {
+	var lines []*zoneLine
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var current *zoneLine
+	depth := 0
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := scanner.Text()
+		if depth == 0 {
+			current = &zoneLine{
+				number:    number,
+				inherited: len(text) > 0 && (text[0] == ' ' || text[0] == '\t'),
+			}
+		}
+		tokens, delta, err := tokenizeZoneLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", number, err)
+		}
+		depth += delta
+		if depth < 0 {
+			return nil, fmt.Errorf("line %d: unbalanced parentheses", number)
+		}
+		current.tokens = append(current.tokens, tokens...)
+		if depth == 0 {
+			lines = append(lines, current)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("line %d: unbalanced parentheses", current.number)
+	}
+	return lines, nil
+}
+
+// tokenizeZoneLine splits the physical line into tokens and returns the change
+// of parentheses depth
+func tokenizeZoneLine(text string) ([]zoneToken, int, error) {
+	var tokens []zoneToken
+	delta := 0
+	i := 0
+	for i < len(text) {
+		c := text[i]
+		switch {
+		case c == ';':
+			return tokens, delta, nil
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '(':
+			delta++
+			i++
+		case c == ')':
+			delta--
+			i++
+		case c == '"':
+			var sb strings.Builder
+			i++
+			closed := false
+			for i < len(text) {
+				if text[i] == '\\' && i+1 < len(text) {
+					sb.WriteByte(text[i])
+					sb.WriteByte(text[i+1])
+					i += 2
+					continue
+				}
+				if text[i] == '"' {
+					closed = true
+					i++
+					break
+				}
+				sb.WriteByte(text[i])
+				i++
+			}
+			if !closed {
+				return nil, 0, fmt.Errorf("unterminated quoted string")
+			}
+			tokens = append(tokens, zoneToken{Text: sb.String(), Quoted: true})
+		default:
+			start := i
+			for i < len(text) && !strings.ContainsRune(" \t\r;()\"", rune(text[i])) {
+				if text[i] == '\\' && i+1 < len(text) {
+					i++
+				}
+				i++
+			}
+			tokens = append(tokens, zoneToken{Text: text[start:i]})
+		}
+	}
+	return tokens, delta, nil
+}
+
+// parseZoneTTL parses TTL in seconds or with BIND units, e.g. 1h30m
+func parseZoneTTL(s string) (int, error) {
+	if ttl, err := strconv.Atoi(s); err == nil {
+		return ttl, nil
+	}
+	units := map[byte]int{'s': 1, 'm': 60, 'h': 3600, 'd': 86400, 'w': 604800}
+	total, current := 0, -1
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= '0' && c <= '9' {
+			if current < 0 {
+				current = 0
+			}
+			current = current*10 + int(c-'0')
+			continue
+		}
+		unit, ok := units[strings.ToLower(string(c))[0]]
+		if !ok || current < 0 {
+			return 0, fmt.Errorf("invalid TTL %q", s)
+		}
+		total += current * unit
+		current = -1
+	}
+	if current >= 0 {
+		return 0, fmt.Errorf("invalid TTL %q", s)
+	}
+	return total, nil
+}
+
+// toFQDN converts the name to absolute name with trailing dot
+func toFQDN(name, origin string) string {
+	if name == "@" {
+		return origin
+	}
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	if origin == "." {
+		return name + "."
+	}
+	return name + "." + origin
+}
+
+func slicesContainsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
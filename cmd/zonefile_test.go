@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseZoneFile(t *testing.T) {
+	zone := `$TTL 1h
+@	IN	SOA	ns1 hostmaster (
+			2024010101 ; serial
+			1d 2h 4w 1h )
+	IN	NS	ns1.example.net.
+www	300	IN	A	192.0.2.1 ; web
+	IN	300	A	192.0.2.2
+$ORIGIN sub.example.com.
+txt		TXT	"v=spf1 -all" "part; two"
+`
+	records, err := parseZoneFile(strings.NewReader(zone), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 5 {
+		t.Fatalf("expected 5 records, got %d", len(records))
+	}
+	soa := records[0]
+	if soa.Name != "example.com." || soa.Type != "SOA" || soa.TTL != 3600 || len(soa.Data) != 7 {
+		t.Errorf("unexpected SOA record %+v", soa)
+	}
+	if records[1].Name != "example.com." || records[1].Type != "NS" || records[1].Line != 5 {
+		t.Errorf("expected inherited owner name, got %+v", records[1])
+	}
+	if records[3].Name != "www.example.com." || records[3].TTL != 300 || records[3].Data[0].Text != "192.0.2.2" {
+		t.Errorf("unexpected A record %+v", records[3])
+	}
+	txt := records[4]
+	if txt.Name != "txt.sub.example.com." || txt.TTL != 3600 || len(txt.Data) != 2 || txt.Data[1].Text != "part; two" {
+		t.Errorf("unexpected TXT record %+v", txt)
+	}
+}
+
+func TestParseZoneFile_errors(t *testing.T) {
+	tests := map[string]string{
+		"$INCLUDE other.zone\n":      "line 1: unsupported directive $INCLUDE",
+		"www A (192.0.2.1\n":         "line 1: unbalanced parentheses",
+		"\tA 192.0.2.1\n":            "line 1: owner name is not defined",
+		"www 300 IN\n":               "line 1: record type is not defined",
+		"www TXT \"unterminated\n":   "line 1: unterminated quoted string",
+		"www 5x A 192.0.2.1\n":       `line 1: invalid TTL "5x"`,
+		"$TTL\n":                     "line 1: $TTL requires a value",
+		"www A 192.0.2.1\n$ORIGIN\n": "line 2: $ORIGIN requires a domain name",
+	}
+	for zone, expected := range tests {
+		_, err := parseZoneFile(strings.NewReader(zone), "example.com")
+		if err == nil || err.Error() != expected {
+			t.Errorf("%q: expected error %q, got %v", zone, expected, err)
+		}
+	}
+}
+
+func TestParseZoneTTL(t *testing.T) {
+	tests := map[string]int{"300": 300, "1h": 3600, "1h30m": 5400, "1W": 604800, "2d3s": 172803}
+	for s, expected := range tests {
+		ttl, err := parseZoneTTL(s)
+		if err != nil {
+			t.Errorf("%q: unexpected error %s", s, err)
+		} else if ttl != expected {
+			t.Errorf("%q: expected %d, got %d", s, expected, ttl)
+		}
+	}
+	for _, s := range []string{"h", "1y", "10m5"} {
+		if _, err := parseZoneTTL(s); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}